@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/xml"
+	"flag"
 	"log"
 	"os"
 
@@ -9,13 +10,17 @@ import (
 )
 
 func main() {
+	dpi := flag.Float64("dpi", svg.DefaultDPI, "the resolution used to convert absolute lengths into pixels")
+	flag.Parse()
+
 	var doc svg.SVG
 	if err := xml.NewDecoder(os.Stdin).Decode(&doc); err != nil {
 		log.Fatal(err)
 	}
 
-	ctx := svg.NewContext(&doc)
-	if err := svg.Render(ctx, &doc); err != nil {
+	opts := &svg.Options{DPI: *dpi}
+	ctx := svg.NewContextWithOptions(&doc, 1, opts)
+	if err := svg.RenderWithOptions(ctx, &doc, opts); err != nil {
 		log.Fatal(err)
 	}
 
@@ -0,0 +1,627 @@
+package svg
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/css"
+)
+
+// attributeSelector represents a CSS attribute selector, e.g. `[type~="text/css"]`.
+type attributeSelector struct {
+	name  string
+	op    string
+	value string
+}
+
+func (s *attributeSelector) matches(e Element) bool {
+	for _, a := range e.attrs().attributes {
+		if a.Name.Local != s.name {
+			continue
+		}
+
+		v := a.Value
+		switch s.op {
+		case "":
+			return true
+		case "=":
+			return v == s.value
+		case "~=":
+			for _, w := range strings.Fields(v) {
+				if w == s.value {
+					return true
+				}
+			}
+			return false
+		case "|=":
+			return v == s.value || strings.HasPrefix(v, s.value+"-")
+		case "^=":
+			return s.value != "" && strings.HasPrefix(v, s.value)
+		case "$=":
+			return s.value != "" && strings.HasSuffix(v, s.value)
+		case "*=":
+			return s.value != "" && strings.Contains(v, s.value)
+		}
+		return false
+	}
+	return false
+}
+
+// compoundSelector represents a sequence of simple selectors that are not separated by a
+// combinator, e.g. `rect.highlight[fill]`.
+type compoundSelector struct {
+	// combinator is the combinator that relates this compound selector to the compound
+	// selector that precedes it: ' ' for the descendant combinator or '>' for the child
+	// combinator.
+	combinator byte
+
+	name       string
+	id         string
+	classes    []string
+	attributes []attributeSelector
+}
+
+func (s *compoundSelector) matches(e Element) bool {
+	if s.name != "" && s.name != "*" && s.name != elementName(e) {
+		return false
+	}
+	if s.id != "" && s.id != e.id() {
+		return false
+	}
+	if len(s.classes) != 0 {
+		classes := strings.Fields(e.attrs().Class)
+		for _, c := range s.classes {
+			found := false
+			for _, class := range classes {
+				if class == c {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	for i := range s.attributes {
+		if !s.attributes[i].matches(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity represents the specificity of a selector as a triple of the number of ID
+// selectors, the number of class, attribute, and pseudo-class selectors, and the number of
+// type selectors it contains.
+type specificity [3]int
+
+func (s specificity) less(t specificity) bool {
+	for i := range s {
+		if s[i] != t[i] {
+			return s[i] < t[i]
+		}
+	}
+	return false
+}
+
+// selector represents a complex CSS selector.
+type selector struct {
+	compounds   []compoundSelector
+	specificity specificity
+}
+
+// matches returns true if the selector matches the given element. The element's ancestors
+// are given in document order, i.e. the element's parent is the last entry in ancestors.
+func (s *selector) matches(e Element, ancestors []Element) bool {
+	return s.matchCompound(len(s.compounds)-1, e, ancestors)
+}
+
+func (s *selector) matchCompound(i int, e Element, ancestors []Element) bool {
+	c := &s.compounds[i]
+	if !c.matches(e) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	switch c.combinator {
+	case '>':
+		if len(ancestors) == 0 {
+			return false
+		}
+		return s.matchCompound(i-1, ancestors[len(ancestors)-1], ancestors[:len(ancestors)-1])
+	default:
+		for j := len(ancestors) - 1; j >= 0; j-- {
+			if s.matchCompound(i-1, ancestors[j], ancestors[:j]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parseSelector parses a complex selector from a list of CSS tokens.
+func parseSelector(tokens []css.Token) (selector, error) {
+	var sel selector
+
+	compound, combinator, empty := compoundSelector{combinator: ' '}, byte(' '), true
+	finishCompound := func() error {
+		if empty {
+			return errors.New("expected a selector")
+		}
+		sel.compounds, compound, empty = append(sel.compounds, compound), compoundSelector{combinator: combinator}, true
+		return nil
+	}
+
+	for len(tokens) > 0 {
+		t := tokens[0]
+		tokens = tokens[1:]
+
+		switch t.TokenType {
+		case css.WhitespaceToken:
+			if !empty {
+				combinator = ' '
+				if err := finishCompound(); err != nil {
+					return selector{}, err
+				}
+			}
+		case css.IdentToken:
+			if !empty {
+				return selector{}, errors.New("unexpected type selector")
+			}
+			compound.name, empty = string(t.Data), false
+			sel.specificity[2]++
+		case css.HashToken:
+			compound.id, empty = string(t.Data[1:]), false
+			sel.specificity[0]++
+		case css.DelimToken:
+			switch t.Data[0] {
+			case '*':
+				if !empty {
+					return selector{}, errors.New("unexpected universal selector")
+				}
+				compound.name, empty = "*", false
+			case '.':
+				if len(tokens) == 0 || tokens[0].TokenType != css.IdentToken {
+					return selector{}, errors.New("expected a class name")
+				}
+				compound.classes, tokens, empty = append(compound.classes, string(tokens[0].Data)), tokens[1:], false
+				sel.specificity[1]++
+			case '>':
+				if !empty {
+					if err := finishCompound(); err != nil {
+						return selector{}, err
+					}
+				}
+				if len(sel.compounds) == 0 {
+					return selector{}, errors.New("expected a selector")
+				}
+				combinator, compound.combinator = '>', '>'
+			default:
+				return selector{}, fmt.Errorf("unsupported selector syntax '%v'", string(t.Data))
+			}
+		case css.LeftBracketToken:
+			var attr attributeSelector
+			var err error
+			attr, tokens, err = parseAttributeSelector(tokens)
+			if err != nil {
+				return selector{}, err
+			}
+			compound.attributes, empty = append(compound.attributes, attr), false
+			sel.specificity[1]++
+		default:
+			return selector{}, fmt.Errorf("unsupported selector syntax '%v'", string(t.Data))
+		}
+	}
+	if empty && (len(sel.compounds) == 0 || compound.combinator == '>') {
+		return selector{}, errors.New("expected a selector")
+	}
+	if !empty {
+		sel.compounds = append(sel.compounds, compound)
+	}
+	return sel, nil
+}
+
+func parseAttributeSelector(tokens []css.Token) (attributeSelector, []css.Token, error) {
+	next := func() (css.Token, bool) {
+		for len(tokens) > 0 {
+			t := tokens[0]
+			tokens = tokens[1:]
+			if t.TokenType != css.WhitespaceToken {
+				return t, true
+			}
+		}
+		return css.Token{}, false
+	}
+
+	name, ok := next()
+	if !ok || name.TokenType != css.IdentToken {
+		return attributeSelector{}, nil, errors.New("expected an attribute name")
+	}
+	attr := attributeSelector{name: string(name.Data)}
+
+	t, ok := next()
+	if !ok {
+		return attributeSelector{}, nil, errors.New("expected ']'")
+	}
+	switch t.TokenType {
+	case css.RightBracketToken:
+		return attr, tokens, nil
+	case css.IncludeMatchToken, css.DashMatchToken, css.PrefixMatchToken, css.SuffixMatchToken, css.SubstringMatchToken:
+		attr.op = string(t.Data)
+	case css.DelimToken:
+		if t.Data[0] != '=' {
+			return attributeSelector{}, nil, errors.New("expected an attribute selector operator")
+		}
+		attr.op = "="
+	default:
+		return attributeSelector{}, nil, errors.New("expected an attribute selector operator")
+	}
+
+	value, ok := next()
+	if !ok {
+		return attributeSelector{}, nil, errors.New("expected an attribute value")
+	}
+	switch value.TokenType {
+	case css.IdentToken:
+		attr.value = string(value.Data)
+	case css.StringToken:
+		attr.value = string(value.Data[1 : len(value.Data)-1])
+	default:
+		return attributeSelector{}, nil, errors.New("expected an identifier or string")
+	}
+
+	if t, ok = next(); !ok || t.TokenType != css.RightBracketToken {
+		return attributeSelector{}, nil, errors.New("expected ']'")
+	}
+	return attr, tokens, nil
+}
+
+// declaration represents a CSS declaration, e.g. `fill: red !important`.
+type declaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+func parseDeclaration(property []byte, tokens []css.Token) declaration {
+	// Trim trailing whitespace and strip any !important annotation.
+	trim := func() {
+		for len(tokens) > 0 && tokens[len(tokens)-1].TokenType == css.WhitespaceToken {
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+
+	important := false
+	trim()
+	if n := len(tokens); n >= 2 {
+		bang, ident := tokens[n-2], tokens[n-1]
+		if bang.TokenType == css.DelimToken && bang.Data[0] == '!' && ident.TokenType == css.IdentToken && strings.EqualFold(string(ident.Data), "important") {
+			tokens, important = tokens[:n-2], true
+			trim()
+		}
+	}
+
+	var value strings.Builder
+	for _, t := range tokens {
+		value.Write(t.Data)
+	}
+	return declaration{property: string(property), value: value.String(), important: important}
+}
+
+// parseDeclarations parses a list of declarations, e.g. the contents of a `style` attribute.
+func parseDeclarations(text string) []declaration {
+	var decls []declaration
+
+	p := css.NewParser(parse.NewInput(strings.NewReader(text)), true)
+	for {
+		gt, _, data := p.Next()
+		switch gt {
+		case css.ErrorGrammar:
+			if p.HasParseError() {
+				// Skip the invalid declaration.
+				continue
+			}
+			return decls
+		case css.DeclarationGrammar:
+			decls = append(decls, parseDeclaration(data, p.Values()))
+		}
+	}
+}
+
+// rule represents a CSS style rule.
+type rule struct {
+	selectors    []selector
+	declarations []declaration
+}
+
+// stylesheet represents a CSS style sheet.
+type stylesheet struct {
+	rules []rule
+}
+
+// parseStylesheet parses a CSS style sheet. Rules with invalid or unsupported selectors are
+// ignored, as are at-rules.
+func parseStylesheet(text string) *stylesheet {
+	var sheet stylesheet
+
+	p := css.NewParser(parse.NewInput(strings.NewReader(text)), false)
+
+	var current *rule
+	valid, depth := true, 0
+	for {
+		gt, _, data := p.Next()
+		switch gt {
+		case css.ErrorGrammar:
+			if p.HasParseError() {
+				continue
+			}
+			return &sheet
+		case css.BeginAtRuleGrammar:
+			depth++
+		case css.EndAtRuleGrammar:
+			depth--
+		case css.QualifiedRuleGrammar, css.BeginRulesetGrammar:
+			if current == nil {
+				current, valid = &rule{}, true
+			}
+			if sel, err := parseSelector(p.Values()); err == nil {
+				current.selectors = append(current.selectors, sel)
+			} else {
+				// If any selector in a selector list is invalid, the entire rule is invalid.
+				valid = false
+			}
+		case css.DeclarationGrammar:
+			if current != nil {
+				current.declarations = append(current.declarations, parseDeclaration(data, p.Values()))
+			}
+		case css.EndRulesetGrammar:
+			if current != nil && valid && depth == 0 {
+				sheet.rules = append(sheet.rules, *current)
+			}
+			current = nil
+		}
+	}
+}
+
+// elementName returns the local name of the given element, e.g. `rect`.
+func elementName(e Element) string {
+	t := reflect.TypeOf(e)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if f, ok := t.FieldByName("XMLName"); ok {
+		return f.Tag.Get("xml")
+	}
+	return ""
+}
+
+var propertyFields sync.Map // map[reflect.Type]map[string][]int
+
+// elementProperties returns a map from attribute name to field index for the presentation
+// attributes of the given element type, which may be set using CSS. Other attributes, such as
+// those that define geometry or reference other elements, are not properties.
+func elementProperties(t reflect.Type) map[string][]int {
+	if fields, ok := propertyFields.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+
+	fields := map[string][]int{}
+
+	var visit func(t reflect.Type, index []int)
+	visit = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fieldIndex := append(append([]int(nil), index...), i)
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				visit(f.Type, fieldIndex)
+				continue
+			}
+
+			tag := f.Tag.Get("xml")
+			if !strings.HasSuffix(tag, ",attr") {
+				continue
+			}
+			name := strings.TrimSuffix(tag, ",attr")
+			if _, ok := propertyGrammars[name]; !ok {
+				continue
+			}
+			if _, ok := fields[name]; !ok {
+				fields[name] = fieldIndex
+			}
+		}
+	}
+	visit(t, nil)
+
+	propertyFields.Store(t, fields)
+	return fields
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setProperty sets the value of the named property on the given element. The value is
+// decoded in the same way as the corresponding presentation attribute.
+func setProperty(e Element, name, value string) error {
+	v := reflect.ValueOf(e).Elem()
+
+	index, ok := elementProperties(v.Type())[name]
+	if !ok {
+		return fmt.Errorf("unknown property %v", name)
+	}
+	field := v.FieldByIndex(index)
+
+	if strings.TrimSpace(value) == "inherit" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	if err := validateProperty(name, value); err != nil {
+		return err
+	}
+
+	t := field.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	decoded := reflect.New(t)
+	switch {
+	case decoded.Type().Implements(textUnmarshalerType):
+		if err := decoded.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+	case t.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return err
+		}
+		decoded.Elem().SetFloat(f)
+	case t.Kind() == reflect.String:
+		decoded.Elem().SetString(strings.TrimSpace(value))
+	default:
+		return fmt.Errorf("cannot set property %v", name)
+	}
+
+	if field.Kind() == reflect.Ptr {
+		field.Set(decoded)
+	} else {
+		field.Set(decoded.Elem())
+	}
+	return nil
+}
+
+// cascade applies the rules in a document's style sheets to its elements.
+type cascade struct {
+	sheets []*stylesheet
+}
+
+func newCascade(svg *SVG) *cascade {
+	var c cascade
+	walk(svg, func(e Element) {
+		if s, ok := e.(*Style); ok && (s.Type == "" || s.Type == "text/css") {
+			c.sheets = append(c.sheets, parseStylesheet(s.Style))
+		}
+	})
+	return &c
+}
+
+type matchedDeclaration struct {
+	declaration
+
+	inline      bool
+	specificity specificity
+	order       int
+}
+
+func (m *matchedDeclaration) less(n *matchedDeclaration) bool {
+	switch {
+	case m.important != n.important:
+		return !m.important
+	case m.inline != n.inline:
+		return !m.inline
+	case m.specificity != n.specificity:
+		return m.specificity.less(n.specificity)
+	default:
+		return m.order < n.order
+	}
+}
+
+// style returns a copy of the given element with its computed style applied. Presentation
+// attributes are overridden by matching style sheet rules, which are in turn overridden by
+// the element's inline style.
+func (c *cascade) style(e Element, ancestors []Element) Element {
+	var matched []matchedDeclaration
+
+	order := 0
+	for _, sheet := range c.sheets {
+		for _, rule := range sheet.rules {
+			var spec specificity
+			matches := false
+			for i := range rule.selectors {
+				if s := &rule.selectors[i]; s.matches(e, ancestors) {
+					if !matches || spec.less(s.specificity) {
+						spec = s.specificity
+					}
+					matches = true
+				}
+			}
+			if matches {
+				for _, d := range rule.declarations {
+					matched = append(matched, matchedDeclaration{declaration: d, specificity: spec, order: order})
+					order++
+				}
+			}
+		}
+	}
+	if style := e.attrs().Style; style != "" {
+		for _, d := range parseDeclarations(style) {
+			matched = append(matched, matchedDeclaration{declaration: d, inline: true, order: order})
+			order++
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].less(&matched[j])
+	})
+
+	styled := reflect.New(reflect.TypeOf(e).Elem())
+	styled.Elem().Set(reflect.ValueOf(e).Elem())
+	result := styled.Interface().(Element)
+
+	for _, d := range matched {
+		// Invalid declarations are ignored.
+		_ = setProperty(result, d.property, d.value)
+	}
+
+	return result
+}
+
+// styleElements returns copies of the given elements and their descendants with their
+// computed styles applied.
+func (c *cascade) styleElements(es []any, ancestors []Element) []any {
+	if es == nil {
+		return nil
+	}
+
+	styled := make([]any, len(es))
+	for i, e := range es {
+		styled[i] = any{X: c.styleElement(e.X, ancestors)}
+	}
+	return styled
+}
+
+func (c *cascade) styleElement(e Element, ancestors []Element) Element {
+	styled := c.style(e, ancestors)
+	ancestors = append(ancestors[:len(ancestors):len(ancestors)], e)
+
+	v := reflect.ValueOf(styled).Elem()
+	if children := v.FieldByName("Children"); children.IsValid() {
+		children.Set(reflect.ValueOf(c.styleElements(children.Interface().([]any), ancestors)))
+	}
+	if stops := v.FieldByName("Stops"); stops.IsValid() {
+		original := stops.Interface().([]GradientStop)
+
+		styledStops := make([]GradientStop, len(original))
+		for i := range original {
+			styledStops[i] = *c.style(&original[i], ancestors).(*GradientStop)
+		}
+		stops.Set(reflect.ValueOf(styledStops))
+	}
+
+	return styled
+}
+
+// applyStyles returns a copy of the given document with the rules in its style sheets and
+// its elements' inline styles applied.
+func applyStyles(svg *SVG) *SVG {
+	c := newCascade(svg)
+	return c.styleElement(svg, nil).(*SVG)
+}
@@ -0,0 +1,146 @@
+package svg
+
+import (
+	"encoding/xml"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectors(t *testing.T) {
+	const doc = `<svg xmlns="http://www.w3.org/2000/svg">
+  <g id="outer" class="a b">
+    <g id="inner">
+      <rect id="r" class="c" data-kind="box-wide" width="1" height="1"/>
+    </g>
+  </g>
+</svg>`
+
+	var svg SVG
+	require.NoError(t, xml.Unmarshal([]byte(doc), &svg))
+
+	outer := svg.Children[0].X
+	inner := outer.(*Grouping).Children[0].X
+	rect := inner.(*Grouping).Children[0].X
+	ancestors := []Element{outer, inner}
+
+	cases := []struct {
+		selector    string
+		matches     bool
+		specificity specificity
+	}{
+		{"rect", true, specificity{0, 0, 1}},
+		{"*", true, specificity{0, 0, 0}},
+		{"#r", true, specificity{1, 0, 0}},
+		{"rect.c", true, specificity{0, 1, 1}},
+		{".a rect", true, specificity{0, 1, 1}},
+		{".a.b #inner > .c", true, specificity{1, 3, 0}},
+		{".a > rect", false, specificity{0, 1, 1}},
+		{"circle", false, specificity{0, 0, 1}},
+		{"[data-kind]", true, specificity{0, 1, 0}},
+		{"[data-kind|=box]", true, specificity{0, 1, 0}},
+		{`[data-kind^="box"]`, true, specificity{0, 1, 0}},
+		{`[data-kind$=wide]`, true, specificity{0, 1, 0}},
+		{`[data-kind="box"]`, false, specificity{0, 1, 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.selector, func(t *testing.T) {
+			sheet := parseStylesheet(c.selector + " {}")
+			require.Len(t, sheet.rules, 1)
+			require.Len(t, sheet.rules[0].selectors, 1)
+
+			sel := sheet.rules[0].selectors[0]
+			assert.Equal(t, c.specificity, sel.specificity)
+			assert.Equal(t, c.matches, sel.matches(rect, ancestors))
+		})
+	}
+}
+
+func TestCascade(t *testing.T) {
+	const doc = `<svg xmlns="http://www.w3.org/2000/svg">
+  <style>
+    rect { fill: blue; stroke: green !important }
+    #r { fill: red; stroke-width: 4 }
+    .c { stroke-width: 2; opacity: 0.5 }
+    a:hover rect { fill: yellow }
+    @media print { rect { fill: orange } }
+  </style>
+  <rect id="r" class="c" fill="black" stroke="black" style="stroke: purple; opacity: 0.25; fill: bogus" width="1" height="1"/>
+</svg>`
+
+	var svg SVG
+	require.NoError(t, xml.NewDecoder(strings.NewReader(doc)).Decode(&svg))
+
+	styled := applyStyles(&svg)
+	rect := styled.Children[1].X.(*Rect)
+
+	// Important declarations in style sheets win over inline styles.
+	assert.Equal(t, color.NRGBA{G: 0x80, A: 0xff}, color.NRGBAModel.Convert(rect.Stroke.Color))
+	// Declarations from more specific selectors win.
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, color.NRGBAModel.Convert(rect.Fill.Color))
+	assert.Equal(t, 4.0, rect.StrokeWidth.Length.Value)
+	// Inline styles win over style sheets.
+	assert.Equal(t, 0.25, rect.Opacity.Number)
+
+	// The original document is unmodified.
+	original := svg.Children[1].X.(*Rect)
+	assert.Equal(t, color.NRGBA{A: 0xff}, color.NRGBAModel.Convert(original.Fill.Color))
+}
+
+func TestStyleElement(t *testing.T) {
+	const doc = `<svg xmlns="http://www.w3.org/2000/svg" style="fill: red">
+  <g><style>g { fill: green }</style></g>
+  <style>rect { fill: blue }</style>
+  <style>circle { fill: blue }</style>
+</svg>`
+
+	var svg SVG
+	require.NoError(t, xml.Unmarshal([]byte(doc), &svg))
+
+	assert.Equal(t, "fill: red", svg.ElementAttributes.Style)
+	require.Len(t, svg.Children, 3)
+	for _, c := range svg.Children[1:] {
+		assert.IsType(t, &Style{}, c.X)
+	}
+
+	// The deprecated Style field holds the first top-level style element.
+	require.NotNil(t, svg.Style)
+	assert.Same(t, svg.Children[1].X, svg.Style)
+	assert.Equal(t, "rect { fill: blue }", svg.Style.Style)
+}
+
+func TestNonPresentationAttributes(t *testing.T) {
+	// Only presentation attributes may be set using CSS, so style sheets and inline styles
+	// cannot change geometry or references.
+	const doc = `<svg xmlns="http://www.w3.org/2000/svg">
+  <style>
+    path { d: "M 0 0 H 10"; fill: blue }
+    polygon { points: 0 0 10 0 10 10 }
+    use { href: #p; x: 10 }
+    #p { id: q; class: c }
+  </style>
+  <path id="p" d="M 0 0 V 10"/>
+  <polygon points="0 0 0 10 10 10" style="points: 1 1"/>
+  <use href="#r"/>
+</svg>`
+
+	var svg SVG
+	require.NoError(t, xml.Unmarshal([]byte(doc), &svg))
+
+	styled := applyStyles(&svg)
+	path := styled.Children[1].X.(*Path)
+	assert.Equal(t, svg.Children[1].X.(*Path).D, path.D)
+	assert.Equal(t, "p", path.ID)
+	assert.Empty(t, path.Class)
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0xff}, color.NRGBAModel.Convert(path.Fill.Color))
+
+	polygon := styled.Children[2].X.(*Polygon)
+	assert.Equal(t, PolyPoints{{0, 0}, {0, 10}, {10, 10}}, polygon.Points)
+
+	use := styled.Children[3].X.(*Use)
+	assert.Equal(t, "#r", use.Href)
+	assert.Equal(t, LengthPercentage{}, use.X)
+}
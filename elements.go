@@ -39,6 +39,31 @@ func (a *any) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
 		a.X = &Switch{}
 	case "marker":
 		a.X = &Marker{}
+	case "clipPath":
+		a.X = &ClipPath{}
+	case "mask":
+		a.X = &Mask{}
+	case "filter":
+		a.X = &Filter{}
+	case "feGaussianBlur":
+		a.X = &FeGaussianBlur{}
+	case "feOffset":
+		a.X = &FeOffset{}
+	case "feFlood":
+		a.X = &FeFlood{}
+	case "feComposite":
+		a.X = &FeComposite{}
+	case "feMerge":
+		a.X = &FeMerge{}
+	case "feColorMatrix":
+		a.X = &FeColorMatrix{}
+	case "feBlend":
+		a.X = &FeBlend{}
+	case "feDropShadow":
+		a.X = &FeDropShadow{}
+	case "feComponentTransfer", "feConvolveMatrix", "feDiffuseLighting", "feDisplacementMap", "feImage",
+		"feMorphology", "feSpecularLighting", "feTile", "feTurbulence":
+		a.X = &FeUnsupported{}
 	case "linearGradient":
 		a.X = &LinearGradient{}
 	case "radialGradient":
@@ -63,24 +88,40 @@ func (a *any) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
 		a.X = &Text{}
 	case "tspan":
 		a.X = &TSpan{}
+	case "textPath":
+		a.X = &TextPath{}
 	case "image":
 		a.X = &Image{}
 	case "foreignObject":
 		a.X = &ForeignObject{}
+	case "style":
+		a.X = &Style{}
 	default:
 		return fmt.Errorf("unrecognized element %v:%v", s.Name.Space, s.Name.Local)
 	}
 
-	return d.DecodeElement(a.X, &s)
+	// Presentation attributes with invalid values are ignored. The raw attributes are
+	// retained for use by CSS attribute selectors.
+	valid := s
+	valid.Attr = validProperties(s.Attr)
+	if err := d.DecodeElement(a.X, &valid); err != nil {
+		return err
+	}
+	a.X.attrs().attributes = s.Attr
+	return nil
 }
 
 // ElementAttributes contains standard SVG element attributes.
 type ElementAttributes struct {
-	ID string `xml:"id,attr"`
+	ID    string `xml:"id,attr"`
+	Class string `xml:"class,attr"`
+	Style string `xml:"style,attr"`
+
+	XMLSpace string `xml:"http://www.w3.org/XML/1998/namespace space,attr"`
 
 	AlignmentBaseline         Ident                        `xml:"alignment-baseline,attr"`
 	BaselineShift             *LengthPercentageIdent       `xml:"baseline-shift,attr"`
-	ClipPath                  *ClipPath                    `xml:"clip-path,attr"`
+	ClipPath                  *ClipSource                  `xml:"clip-path,attr"`
 	ClipRule                  Ident                        `xml:"clip-rule,attr"`
 	Color                     *Color                       `xml:"color,attr"`
 	ColorInterpolation        Ident                        `xml:"color-interpolation,attr"`
@@ -104,13 +145,16 @@ type ElementAttributes struct {
 	FontVariant               Ident                        `xml:"font-variant,attr"`
 	FontWeight                *NumberIdent                 `xml:"font-weight,attr"`
 	ImageRendering            Ident                        `xml:"image-rendering,attr"`
+	Isolation                 Ident                        `xml:"isolation,attr"`
 	LetterSpacing             *LengthIdent                 `xml:"letter-spacing,attr"`
 	LightingColor             *Color                       `xml:"lighting-color,attr"`
 	MarkerEnd                 *URLIdent                    `xml:"marker-end,attr"`
 	MarkerMid                 *URLIdent                    `xml:"marker-mid,attr"`
 	MarkerStart               *URLIdent                    `xml:"marker-start,attr"`
-	Mask                      *Mask                        `xml:"mask,attr"`
-	Opacity                   *NumberIdent                 `xml:"opacity,attr"`
+	Mask                      *URLIdent                    `xml:"mask,attr"`
+	MaskType                  Ident                        `xml:"mask-type,attr"`
+	MixBlendMode              Ident                        `xml:"mix-blend-mode,attr"`
+	Opacity                   *NumberPercentage            `xml:"opacity,attr"`
 	Overflow                  Ident                        `xml:"overflow,attr"`
 	PaintOrder                Ident                        `xml:"paint-order,attr"`
 	PointerEvents             Ident                        `xml:"pointer-events,attr"`
@@ -127,13 +171,16 @@ type ElementAttributes struct {
 	TextDecoration            Ident                        `xml:"text-decoration,attr"`
 	TextOverflow              Ident                        `xml:"text-overflow,attr"`
 	TextRendering             Ident                        `xml:"text-rendering,attr"`
-	Transform                 []Transform                  `xml:"transform,attr"`
+	Transform                 *TransformList               `xml:"transform,attr"`
 	UnicodeBidi               Ident                        `xml:"unicode-bidi,attr"`
 	VectorEffect              *VectorEffect                `xml:"vector-effect,attr"`
 	Visibility                Ident                        `xml:"visibility,attr"`
 	WhiteSpace                Ident                        `xml:"white-space,attr"`
 	WordSpacing               *LengthIdent                 `xml:"word-spacing,attr"`
 	WritingMode               Ident                        `xml:"writing-mode,attr"`
+
+	// attributes holds the element's raw attributes for use by CSS attribute selectors.
+	attributes []xml.Attr
 }
 
 func (ea *ElementAttributes) id() string {
@@ -172,16 +219,16 @@ type Symbol struct {
 
 	XMLName xml.Name `xml:"symbol"`
 
-	PreserveAspectRatio string `xml:"preserveAspectRatio,attr"`
-	ViewBox             string `xml:"viewBox,attr"`
+	PreserveAspectRatio PreserveAspectRatio `xml:"preserveAspectRatio,attr"`
+	ViewBox             *ViewBox            `xml:"viewBox,attr"`
 
 	RefX Length `xml:"refX,attr"`
 	RefY Length `xml:"refY,attr"`
 
-	X      LengthPercentage    `xml:"x,attr"`
-	Y      LengthPercentage    `xml:"y,attr"`
-	Width  BoxLengthPercentage `xml:"width,attr"`
-	Height BoxLengthPercentage `xml:"height,attr"`
+	X      LengthPercentage     `xml:"x,attr"`
+	Y      LengthPercentage     `xml:"y,attr"`
+	Width  *BoxLengthPercentage `xml:"width,attr"`
+	Height *BoxLengthPercentage `xml:"height,attr"`
 
 	Children []any `xml:",any"`
 }
@@ -196,10 +243,10 @@ type Use struct {
 
 	Href string `xml:"href,attr"`
 
-	X      LengthPercentage    `xml:"x,attr"`
-	Y      LengthPercentage    `xml:"y,attr"`
-	Width  BoxLengthPercentage `xml:"width,attr"`
-	Height BoxLengthPercentage `xml:"height,attr"`
+	X      LengthPercentage     `xml:"x,attr"`
+	Y      LengthPercentage     `xml:"y,attr"`
+	Width  *BoxLengthPercentage `xml:"width,attr"`
+	Height *BoxLengthPercentage `xml:"height,attr"`
 }
 
 func (Use) isElement() {}
@@ -221,19 +268,51 @@ type Marker struct {
 
 	XMLName xml.Name `xml:"marker"`
 
-	PreserveAspectRatio string `xml:"preserveAspectRatio,attr"`
-	ViewBox             string `xml:"viewBox,attr"`
+	PreserveAspectRatio PreserveAspectRatio `xml:"preserveAspectRatio,attr"`
+	ViewBox             *ViewBox            `xml:"viewBox,attr"`
 
 	RefX Length `xml:"refX,attr"`
 	RefY Length `xml:"refY,attr"`
 
-	MarkerUnits  string                 `xml:"markerUnits,attr"`
-	MarkerWidth  LengthPercentageNumber `xml:"markerWidth,attr"`
-	MarkerHeight LengthPercentageNumber `xml:"markerHeight,attr"`
+	MarkerUnits  string                  `xml:"markerUnits,attr"`
+	MarkerWidth  *LengthPercentageNumber `xml:"markerWidth,attr"`
+	MarkerHeight *LengthPercentageNumber `xml:"markerHeight,attr"`
 
-	Orient string `xml:"orient,attr"`
+	Orient Orient `xml:"orient,attr"`
 
 	Children []any `xml:",any"`
 }
 
 func (Marker) isElement() {}
+
+// ClipPath represents an SVG `clipPath` element.
+type ClipPath struct {
+	ElementAttributes
+
+	XMLName xml.Name `xml:"clipPath"`
+
+	ClipPathUnits Units `xml:"clipPathUnits,attr"`
+
+	Children []any `xml:",any"`
+}
+
+func (ClipPath) isElement() {}
+
+// Mask represents an SVG `mask` element.
+type Mask struct {
+	ElementAttributes
+
+	XMLName xml.Name `xml:"mask"`
+
+	X      *LengthPercentage `xml:"x,attr"`
+	Y      *LengthPercentage `xml:"y,attr"`
+	Width  *LengthPercentage `xml:"width,attr"`
+	Height *LengthPercentage `xml:"height,attr"`
+
+	MaskUnits        Units `xml:"maskUnits,attr"`
+	MaskContentUnits Units `xml:"maskContentUnits,attr"`
+
+	Children []any `xml:",any"`
+}
+
+func (Mask) isElement() {}
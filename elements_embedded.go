@@ -10,9 +10,9 @@ type Image struct {
 
 	XMLName xml.Name `xml:"image"`
 
-	PreserveAspectRatio string `xml:"preserveAspectRatio,attr"`
-	Href                string `xml:"href,attr"`
-	CrossOrigin         string `xml:"crossOrigin,attr"`
+	PreserveAspectRatio PreserveAspectRatio `xml:"preserveAspectRatio,attr"`
+	Href                string              `xml:"href,attr"`
+	CrossOrigin         string              `xml:"crossOrigin,attr"`
 
 	X      LengthPercentage    `xml:"x,attr"`
 	Y      LengthPercentage    `xml:"y,attr"`
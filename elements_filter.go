@@ -0,0 +1,145 @@
+package svg
+
+import "encoding/xml"
+
+// Filter represents an SVG `filter` element.
+type Filter struct {
+	ElementAttributes
+
+	XMLName xml.Name `xml:"filter"`
+
+	X      *LengthPercentage `xml:"x,attr"`
+	Y      *LengthPercentage `xml:"y,attr"`
+	Width  *LengthPercentage `xml:"width,attr"`
+	Height *LengthPercentage `xml:"height,attr"`
+
+	FilterUnits    Units `xml:"filterUnits,attr"`
+	PrimitiveUnits Units `xml:"primitiveUnits,attr"`
+
+	Children []any `xml:",any"`
+}
+
+func (Filter) isElement() {}
+
+// FilterPrimitive holds fields common to all filter primitive elements.
+type FilterPrimitive struct {
+	ElementAttributes
+
+	X      *LengthPercentage `xml:"x,attr"`
+	Y      *LengthPercentage `xml:"y,attr"`
+	Width  *LengthPercentage `xml:"width,attr"`
+	Height *LengthPercentage `xml:"height,attr"`
+
+	Result string `xml:"result,attr"`
+}
+
+func (FilterPrimitive) isElement() {}
+
+// FeGaussianBlur represents an SVG `feGaussianBlur` element.
+type FeGaussianBlur struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feGaussianBlur"`
+
+	In           string  `xml:"in,attr"`
+	StdDeviation Numbers `xml:"stdDeviation,attr"`
+	EdgeMode     string  `xml:"edgeMode,attr"`
+}
+
+// FeOffset represents an SVG `feOffset` element.
+type FeOffset struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feOffset"`
+
+	In string  `xml:"in,attr"`
+	Dx float64 `xml:"dx,attr"`
+	Dy float64 `xml:"dy,attr"`
+}
+
+// FeFlood represents an SVG `feFlood` element. The flood's color and opacity are given by
+// the flood-color and flood-opacity properties.
+type FeFlood struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feFlood"`
+}
+
+// FeComposite represents an SVG `feComposite` element.
+type FeComposite struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feComposite"`
+
+	In       string  `xml:"in,attr"`
+	In2      string  `xml:"in2,attr"`
+	Operator string  `xml:"operator,attr"`
+	K1       float64 `xml:"k1,attr"`
+	K2       float64 `xml:"k2,attr"`
+	K3       float64 `xml:"k3,attr"`
+	K4       float64 `xml:"k4,attr"`
+}
+
+// FeMerge represents an SVG `feMerge` element.
+type FeMerge struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feMerge"`
+
+	Nodes []FeMergeNode `xml:"feMergeNode"`
+}
+
+// FeMergeNode represents an SVG `feMergeNode` element.
+type FeMergeNode struct {
+	ElementAttributes
+
+	XMLName xml.Name `xml:"feMergeNode"`
+
+	In string `xml:"in,attr"`
+}
+
+func (FeMergeNode) isElement() {}
+
+// FeColorMatrix represents an SVG `feColorMatrix` element.
+type FeColorMatrix struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feColorMatrix"`
+
+	In     string   `xml:"in,attr"`
+	Type   string   `xml:"type,attr"`
+	Values *Numbers `xml:"values,attr"`
+}
+
+// FeBlend represents an SVG `feBlend` element.
+type FeBlend struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feBlend"`
+
+	In   string `xml:"in,attr"`
+	In2  string `xml:"in2,attr"`
+	Mode string `xml:"mode,attr"`
+}
+
+// FeDropShadow represents an SVG `feDropShadow` element. The shadow's color and opacity are
+// given by the flood-color and flood-opacity properties.
+type FeDropShadow struct {
+	FilterPrimitive
+
+	XMLName xml.Name `xml:"feDropShadow"`
+
+	In           string   `xml:"in,attr"`
+	Dx           *float64 `xml:"dx,attr"`
+	Dy           *float64 `xml:"dy,attr"`
+	StdDeviation *Numbers `xml:"stdDeviation,attr"`
+}
+
+// FeUnsupported represents a filter primitive element that is recognized but not supported,
+// such as `feTurbulence` or `feMorphology`. The element's name is recorded in XMLName. An
+// unsupported primitive produces a transparent black result.
+type FeUnsupported struct {
+	FilterPrimitive
+
+	XMLName xml.Name
+}
@@ -13,10 +13,10 @@ type PaintElement interface {
 type Gradient struct {
 	ElementAttributes
 
-	GradientUnits     Units       `xml:"gradientUnits,attr"`
-	GradientTransform []Transform `xml:"gradientTransform,attr"`
-	SpreadMethod      string      `xml:"spreadMethod,attr"`
-	Href              string      `xml:"href,attr"`
+	GradientUnits     Units          `xml:"gradientUnits,attr"`
+	GradientTransform *TransformList `xml:"gradientTransform,attr"`
+	SpreadMethod      string         `xml:"spreadMethod,attr"`
+	Href              string         `xml:"href,attr"`
 
 	Stops []GradientStop `xml:"stop"`
 }
@@ -30,21 +30,23 @@ type GradientStop struct {
 
 	XMLName xml.Name `xml:"stop"`
 
-	Offset  NumberPercentage `xml:"offset,attr"`
-	Color   Color            `xml:"stop-color,attr"`
-	Opacity NumberPercentage `xml:"stop-opacity,attr"`
+	Offset  NumberPercentage  `xml:"offset,attr"`
+	Color   Color             `xml:"stop-color,attr"`
+	Opacity *NumberPercentage `xml:"stop-opacity,attr"`
 }
 
+func (GradientStop) isElement() {}
+
 // LinearGradient represents an SVG `linearGradient` element.
 type LinearGradient struct {
 	Gradient
 
 	XMLName xml.Name `xml:"linearGradient"`
 
-	X1 LengthPercentage `xml:"x1,attr"`
-	Y1 LengthPercentage `xml:"y1,attr"`
-	X2 LengthPercentage `xml:"x2,attr"`
-	Y2 LengthPercentage `xml:"y2,attr"`
+	X1 *LengthPercentage `xml:"x1,attr"`
+	Y1 *LengthPercentage `xml:"y1,attr"`
+	X2 *LengthPercentage `xml:"x2,attr"`
+	Y2 *LengthPercentage `xml:"y2,attr"`
 }
 
 // RadialGradient represents an SVG `radialGradient` element.
@@ -53,12 +55,12 @@ type RadialGradient struct {
 
 	XMLName xml.Name `xml:"radialGradient"`
 
-	Cx LengthPercentage `xml:"cx,attr"`
-	Cy LengthPercentage `xml:"cy,attr"`
-	R  LengthPercentage `xml:"r,attr"`
-	Fx LengthPercentage `xml:"fx,attr"`
-	Fy LengthPercentage `xml:"fy,attr"`
-	Fr LengthPercentage `xml:"fr,attr"`
+	Cx *LengthPercentage `xml:"cx,attr"`
+	Cy *LengthPercentage `xml:"cy,attr"`
+	R  *LengthPercentage `xml:"r,attr"`
+	Fx *LengthPercentage `xml:"fx,attr"`
+	Fy *LengthPercentage `xml:"fy,attr"`
+	Fr *LengthPercentage `xml:"fr,attr"`
 }
 
 // Pattern represents an SVG `pattern` element.
@@ -67,18 +69,18 @@ type Pattern struct {
 
 	XMLName xml.Name `xml:"pattern"`
 
-	ViewBox             string `xml:"viewBox,attr"`
-	PreserveAspectRatio string `xml:"preserveAspectRatio,attr"`
+	ViewBox             *ViewBox            `xml:"viewBox,attr"`
+	PreserveAspectRatio PreserveAspectRatio `xml:"preserveAspectRatio,attr"`
 
-	X      Length `xml:"x,attr"`
-	Y      Length `xml:"y,attr"`
-	Width  Length `xml:"width,attr"`
-	Height Length `xml:"height,attr"`
+	X      *LengthPercentage `xml:"x,attr"`
+	Y      *LengthPercentage `xml:"y,attr"`
+	Width  *LengthPercentage `xml:"width,attr"`
+	Height *LengthPercentage `xml:"height,attr"`
 
 	PatternUnits        Units `xml:"patternUnits,attr"`
 	PatternContentUnits Units `xml:"patternContentUnits,attr"`
 
-	PatternTransform []Transform `xml:"patternTransform,attr"`
+	PatternTransform *TransformList `xml:"patternTransform,attr"`
 
 	Href string `xml:"href,attr"`
 
@@ -91,7 +91,7 @@ type CubicBezier struct {
 
 func (*CubicBezier) isPathCommand() {}
 
-// QuadraticBezierCoordinates represents a set of cubic Bezier curve coordinates.
+// QuadraticBezierCoordinates represents a set of quadratic Bezier curve coordinates.
 type QuadraticBezierCoordinates struct {
 	Point
 
@@ -99,9 +99,10 @@ type QuadraticBezierCoordinates struct {
 	Y1 float64
 }
 
-// QuadraticBezier represents an SVG cubic Bezier curve command.
+// QuadraticBezier represents an SVG quadratic Bezier curve command.
 type QuadraticBezier struct {
 	IsAbsolute bool
+	IsSmooth   bool
 
 	Coordinates []QuadraticBezierCoordinates
 }
@@ -655,27 +656,34 @@ func parseCubicBezier(r *bufio.Reader, isAbsolute, isSmooth bool) (*CubicBezier,
 }
 
 func parseQuadraticBezier(r *bufio.Reader, isAbsolute, isSmooth bool) (*QuadraticBezier, error) {
-	if isSmooth {
-		return nil, errors.New("NYI: smooth quadratic bezier curve")
-	}
-
 	var coords []QuadraticBezierCoordinates
 
-	points, err := parseCoordinatePairDoubleSequence(r)
-	if err != nil {
-		return nil, err
-	}
+	if !isSmooth {
+		points, err := parseCoordinatePairDoubleSequence(r)
+		if err != nil {
+			return nil, err
+		}
 
-	for i := 0; i < len(points); i += 2 {
-		c1, c := points[i], points[i+1]
-		coords = append(coords, QuadraticBezierCoordinates{
-			Point: c,
-			X1:    c1.X,
-			Y1:    c1.Y,
-		})
+		for i := 0; i < len(points); i += 2 {
+			c1, c := points[i], points[i+1]
+			coords = append(coords, QuadraticBezierCoordinates{
+				Point: c,
+				X1:    c1.X,
+				Y1:    c1.Y,
+			})
+		}
+	} else {
+		points, err := parseCoordinatePairSequence(r)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range points {
+			coords = append(coords, QuadraticBezierCoordinates{Point: c})
+		}
 	}
 
-	return &QuadraticBezier{IsAbsolute: isAbsolute, Coordinates: coords}, nil
+	return &QuadraticBezier{IsAbsolute: isAbsolute, IsSmooth: isSmooth, Coordinates: coords}, nil
 }
 
 func parseEllipticalArc(r *bufio.Reader, isAbsolute bool) (*EllipticalArc, error) {
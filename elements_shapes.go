@@ -56,8 +56,8 @@ type Ellipse struct {
 	Cx LengthPercentage `xml:"cx,attr"`
 	Cy LengthPercentage `xml:"cy,attr"`
 
-	Rx BoxLengthPercentage `xml:"rx,attr"`
-	Ry BoxLengthPercentage `xml:"ry,attr"`
+	Rx *BoxLengthPercentage `xml:"rx,attr"`
+	Ry *BoxLengthPercentage `xml:"ry,attr"`
 
 	Children []any `xml:",any"`
 }
@@ -82,12 +82,32 @@ type Line struct {
 
 func (Line) isElement() {}
 
+// PolyPoints represents the list of points in a `polyline` or `polygon` element.
 type PolyPoints []Point
 
 func (p *PolyPoints) UnmarshalText(text []byte) error {
-	points, err := parseCoordinatePairSequence(bufio.NewReader(bytes.NewReader(text)))
-	if err != nil {
-		return err
+	text = bytes.TrimSpace(text)
+	if len(text) == 0 {
+		*p = nil
+		return nil
+	}
+
+	// A list with an odd number of coordinates is in error. The last, odd coordinate is dropped
+	// and the remaining points are rendered.
+	r := bufio.NewReader(bytes.NewReader(text))
+
+	var points []Point
+	for {
+		point, err := parseCoordinatePair(r)
+		if err != nil {
+			break
+		}
+		points = append(points, point)
+
+		more, err := parseOptionalComma(r)
+		if err != nil || !more {
+			break
+		}
 	}
 	*p = points
 	return nil
@@ -99,6 +119,8 @@ type Polyline struct {
 
 	XMLName xml.Name `xml:"polyline"`
 
+	PathLength float64 `xml:"pathLength,attr"`
+
 	Points PolyPoints `xml:"points,attr"`
 
 	Children []any `xml:",any"`
@@ -112,6 +134,8 @@ type Polygon struct {
 
 	XMLName xml.Name `xml:"polygon"`
 
+	PathLength float64 `xml:"pathLength,attr"`
+
 	Points PolyPoints `xml:"points,attr"`
 
 	Children []any `xml:",any"`
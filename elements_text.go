@@ -2,6 +2,8 @@ package svg
 
 import (
 	"encoding/xml"
+	"io"
+	"strings"
 )
 
 // Text represents an SVG `text` element.
@@ -17,13 +19,28 @@ type Text struct {
 
 	Rotate LengthPercentageNumbers `xml:"rotate,attr"`
 
-	TextLength LengthPercentageNumber `xml:"textLength,attr"`
+	TextLength   LengthPercentageNumber `xml:"textLength,attr"`
+	LengthAdjust string                 `xml:"lengthAdjust,attr"`
 
-	Value string `xml:",chardata"`
+	// Value holds the element's character data, excluding that of its child elements.
+	//
+	// Deprecated: Value does not record where character data lies relative to child elements.
+	// Use Children instead.
+	Value string `xml:"-"`
+
+	// Children holds the element's character data and child elements in document order.
+	Children []any `xml:",any"`
 }
 
 func (Text) isElement() {}
 
+func (t *Text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type text Text
+	children, err := unmarshalTextContent(d, start, (*text)(t))
+	t.Children, t.Value = children, charData(children)
+	return err
+}
+
 // TSpan represents an SVG `tspan` element.
 type TSpan struct {
 	ElementAttributes
@@ -40,11 +57,25 @@ type TSpan struct {
 	TextLength   LengthPercentageNumber `xml:"textLength,attr"`
 	LengthAdjust string                 `xml:"lengthAdjust,attr"`
 
-	Value string `xml:",chardata"`
+	// Value holds the element's character data, excluding that of its child elements.
+	//
+	// Deprecated: Value does not record where character data lies relative to child elements.
+	// Use Children instead.
+	Value string `xml:"-"`
+
+	// Children holds the element's character data and child elements in document order.
+	Children []any `xml:",any"`
 }
 
 func (TSpan) isElement() {}
 
+func (t *TSpan) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type tspan TSpan
+	children, err := unmarshalTextContent(d, start, (*tspan)(t))
+	t.Children, t.Value = children, charData(children)
+	return err
+}
+
 // TextPath represents an SVG `textPath` element.
 type TextPath struct {
 	ElementAttributes
@@ -61,7 +92,96 @@ type TextPath struct {
 	TextLength   LengthPercentageNumber `xml:"textLength,attr"`
 	LengthAdjust string                 `xml:"lengthAdjust,attr"`
 
-	Value string `xml:",chardata"`
+	// Value holds the element's character data, excluding that of its child elements.
+	//
+	// Deprecated: Value does not record where character data lies relative to child elements.
+	// Use Children instead.
+	Value string `xml:"-"`
+
+	// Children holds the element's character data and child elements in document order.
+	Children []any `xml:",any"`
 }
 
 func (TextPath) isElement() {}
+
+func (t *TextPath) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type textPath TextPath
+	children, err := unmarshalTextContent(d, start, (*textPath)(t))
+	t.Children, t.Value = children, charData(children)
+	return err
+}
+
+// CharData represents a run of character data within a text content element.
+type CharData struct {
+	ElementAttributes
+
+	Value string
+}
+
+func (CharData) isElement() {}
+
+func (c *CharData) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeToken(xml.CharData(c.Value))
+}
+
+// charData returns the concatenation of the character data in the given children.
+func charData(children []any) string {
+	var b strings.Builder
+	for _, c := range children {
+		if c, ok := c.X.(*CharData); ok {
+			b.WriteString(c.Value)
+		}
+	}
+	return b.String()
+}
+
+// tokenList is an xml.TokenReader that returns a fixed list of tokens.
+type tokenList []xml.Token
+
+func (l *tokenList) Token() (xml.Token, error) {
+	if len(*l) == 0 {
+		return nil, io.EOF
+	}
+	t := (*l)[0]
+	*l = (*l)[1:]
+	return t, nil
+}
+
+// unmarshalTextContent decodes a text content element. The element's attributes are decoded
+// into v, which must not implement xml.Unmarshaler, and the element's character data and
+// child elements are returned in document order.
+func unmarshalTextContent(d *xml.Decoder, start xml.StartElement, v interface{}) ([]any, error) {
+	attrs := tokenList{start, start.End()}
+	if err := xml.NewTokenDecoder(&attrs).Decode(v); err != nil {
+		return nil, err
+	}
+
+	var children []any
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.CharData:
+			// Character data that is interrupted by comments or processing instructions is
+			// merged into a single node.
+			if n := len(children); n != 0 {
+				if c, ok := children[n-1].X.(*CharData); ok {
+					c.Value += string(tok)
+					break
+				}
+			}
+			children = append(children, any{X: &CharData{Value: string(tok)}})
+		case xml.StartElement:
+			var child any
+			if err := child.UnmarshalXML(d, tok); err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		case xml.EndElement:
+			return children, nil
+		}
+	}
+}
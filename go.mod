@@ -6,6 +6,7 @@ require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/flopp/go-findfont v0.0.0-20201114153133-e7393a00c15b
 	github.com/fogleman/gg v1.3.0
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/llgcode/draw2d v0.0.0-20200930101115-bfaf5d914d1e
 	github.com/stretchr/testify v1.7.0
 	github.com/tdewolff/parse/v2 v2.5.10
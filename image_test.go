@@ -1,7 +1,10 @@
 package svg
 
 import (
+	"encoding/xml"
+	"fmt"
 	"image"
+	"image/color"
 	"os"
 	"path/filepath"
 	"testing"
@@ -20,8 +23,123 @@ func decodeTestdata(path string) (image.Image, string, error) {
 	return image.Decode(f)
 }
 
+// renderString renders the given SVG document.
+func renderString(t *testing.T, doc string) image.Image {
+	return renderStringWithOptions(t, doc, nil)
+}
+
+// renderStringWithOptions renders the given SVG document using the given options.
+func renderStringWithOptions(t *testing.T, doc string, opts *Options) image.Image {
+	var svg SVG
+	require.NoError(t, xml.Unmarshal([]byte(doc), &svg))
+
+	ctx := NewContextWithOptions(&svg, 1, opts)
+	require.NoError(t, RenderWithOptions(ctx, &svg, opts))
+	return ctx.Image()
+}
+
+// renderElements renders a document of the given size that contains the given elements.
+func renderElements(t *testing.T, width, height int, elements string) image.Image {
+	return renderString(t, fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">%s</svg>`, width, height, elements))
+}
+
+var (
+	red         = color.NRGBA{R: 0xff, A: 0xff}
+	blue        = color.NRGBA{B: 0xff, A: 0xff}
+	transparent = color.NRGBA{}
+)
+
+// at returns the non-premultiplied color of the given pixel.
+func at(img image.Image, x, y int) color.NRGBA {
+	return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+}
+
+// paintedBounds returns the bounds of the opaque pixels of the given color.
+func paintedBounds(img image.Image, c color.NRGBA) image.Rectangle {
+	near := func(a, b uint8) bool {
+		d := int(a) - int(b)
+		return -8 < d && d < 8
+	}
+
+	var bounds image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			p := at(img, x, y)
+			if p.A == 255 && near(p.R, c.R) && near(p.G, c.G) && near(p.B, c.B) {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return bounds
+}
+
+// subImage returns the portion of the given image within r.
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	return img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}).SubImage(r)
+}
+
+// assertColor asserts that the given pixel is within 2 of the expected color per channel.
+func assertColor(t *testing.T, img image.Image, x, y int, expected color.NRGBA) {
+	t.Helper()
+
+	c := at(img, x, y)
+	if expected.A == 0 {
+		assert.Equal(t, uint8(0), c.A, "(%v, %v)", x, y)
+		return
+	}
+	assert.InDelta(t, int(expected.R), int(c.R), 2, "(%v, %v): %v", x, y, c)
+	assert.InDelta(t, int(expected.G), int(c.G), 2, "(%v, %v): %v", x, y, c)
+	assert.InDelta(t, int(expected.B), int(c.B), 2, "(%v, %v): %v", x, y, c)
+	assert.InDelta(t, int(expected.A), int(c.A), 2, "(%v, %v): %v", x, y, c)
+}
+
 func TestBadgeImage(t *testing.T) {
 	_, format, err := decodeTestdata("badge.svg")
 	require.NoError(t, err)
 	assert.Equal(t, "svg", format)
 }
+
+func TestDuckImage(t *testing.T) {
+	img, _, err := decodeTestdata("duck.svg")
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 200), img.Bounds())
+}
+
+func TestTestImage(t *testing.T) {
+	doc, err := parseTestdata("test.svg")
+	require.NoError(t, err)
+
+	ctx := NewContext(doc)
+	require.NoError(t, Render(ctx, doc))
+
+	img := ctx.Image()
+	assert.Equal(t, image.Rect(0, 0, 620, 472), img.Bounds())
+
+	// The color bars are instantiated by use elements that set their fill. The blue bar is
+	// sampled above the text that overlays the bars.
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, A: 0xff}, color.NRGBAModel.Convert(img.At(115, 100)))
+	assert.Equal(t, color.NRGBA{B: 0xff, A: 0xff}, color.NRGBAModel.Convert(img.At(500, 30)))
+}
+
+func TestPaintAlpha(t *testing.T) {
+	for _, fill := range []string{"rgba(0,0,255,0.5)", "#0000ff80"} {
+		t.Run(fill, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+				<rect width="10" height="10" fill="`+fill+`"/>
+				<rect width="10" height="10" y="5" fill="`+fill+`" fill-opacity="0.5"/>
+			</svg>`)
+
+			c := at(img, 2, 2)
+			assert.Equal(t, uint8(255), c.B)
+			assert.InDelta(t, 128, int(c.A), 2)
+
+			// The color's alpha is multiplied into the fill opacity: .25 over .5.
+			c = at(img, 2, 7)
+			assert.Equal(t, uint8(255), c.B)
+			assert.InDelta(t, 160, int(c.A), 2)
+		})
+	}
+}
@@ -46,7 +46,7 @@ func (l *lexer) readRune() (rune, error) {
 		return rune(buf[0]), nil
 	}
 
-	if _, err := l.r.Read(buf[1 : sz-1]); err != nil {
+	if _, err := io.ReadFull(l.r, buf[1:sz]); err != nil {
 		return 0, err
 	}
 
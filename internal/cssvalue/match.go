@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strconv"
+	"strings"
 
 	"github.com/tdewolff/parse/v2"
 	"github.com/tdewolff/parse/v2/css"
@@ -30,12 +32,16 @@ type Capture struct {
 	Values []Token
 }
 
+// Match matches the value read from r against the given term. If the term matches the entire
+// value, Match returns the captures produced by the match. The final capture is always unnamed
+// and contains all of the tokens in the value, excluding whitespace. If the term does not
+// match, Match returns nil.
 func Match(ctx *Context, term Term, r io.Reader) []Capture {
 	matcher := matcher{
 		context: ctx,
 		lexer:   css.NewLexer(parse.NewInput(r)),
 	}
-	if !matcher.match(term) {
+	if !matcher.match(term) || matcher.peek(1).Type != css.ErrorToken {
 		return nil
 	}
 	matcher.captures = append(matcher.captures, Capture{
@@ -159,13 +165,103 @@ var ratioTerm = &Seq{
 	},
 }
 
+// units lists the units permitted for each dimension type.
+var units = map[string][]string{
+	"length":     {"em", "rem", "ex", "rex", "cap", "rcap", "ch", "rch", "ic", "ric", "lh", "rlh", "vw", "vh", "vi", "vb", "vmin", "vmax", "cm", "mm", "q", "in", "pt", "pc", "px"},
+	"angle":      {"deg", "grad", "rad", "turn"},
+	"time":       {"s", "ms"},
+	"frequency":  {"hz", "khz"},
+	"resolution": {"dpi", "dpcm", "dppx", "x"},
+}
+
+// splitDimension splits a number or dimension token into its numeric value and its units.
+func splitDimension(v string) (float64, string, bool) {
+	// The numeric part of the token is its longest prefix that is a valid number.
+	for i := len(v); i > 0; i-- {
+		if n, err := strconv.ParseFloat(v[:i], 64); err == nil {
+			return n, v[i:], true
+		}
+	}
+	return 0, "", false
+}
+
+// inRange returns true if the given value falls within the given range.
+func inRange(v float64, r *Range) bool {
+	return r == nil || v >= r.Min && v <= r.Max
+}
+
+func (m *matcher) matchNumeric(t *BasicType) bool {
+	next := m.peek(1)
+
+	var value float64
+	switch t.Name {
+	case "integer", "number":
+		if next.Type != css.NumberToken {
+			return false
+		}
+		if t.Name == "integer" && strings.ContainsAny(next.Value, ".eE") {
+			return false
+		}
+		n, _, ok := splitDimension(next.Value)
+		if !ok {
+			return false
+		}
+		value = n
+	case "percentage":
+		if next.Type != css.PercentageToken {
+			return false
+		}
+		n, _, ok := splitDimension(next.Value[:len(next.Value)-1])
+		if !ok {
+			return false
+		}
+		value = n
+	default:
+		// A unitless zero is a valid length.
+		if t.Name == "length" && next.Type == css.NumberToken {
+			n, _, ok := splitDimension(next.Value)
+			if !ok || n != 0 {
+				return false
+			}
+			break
+		}
+
+		if next.Type != css.DimensionToken {
+			return false
+		}
+		n, unit, ok := splitDimension(next.Value)
+		if !ok {
+			return false
+		}
+		if permitted, ok := units[t.Name]; ok {
+			found := false
+			for _, u := range permitted {
+				if strings.EqualFold(unit, u) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		value = n
+	}
+
+	if !inRange(value, t.Range) {
+		return false
+	}
+	m.chomp()
+	return true
+}
+
 func (m *matcher) matchBasicType(t *BasicType) bool {
 	if m.peek(1).Type == css.FunctionToken {
 		f, ok := m.matchFunction()
 		if !ok {
 			return false
 		}
-		return f.Type.Name == t.Name
+		return f.Type != nil && f.Type.Name == t.Name
 	}
 
 	switch t.Name {
@@ -197,27 +293,8 @@ func (m *matcher) matchBasicType(t *BasicType) bool {
 		}
 		m.chomp()
 		return true
-	case "integer", "number":
-		next := m.peek(1)
-		if next.Type != css.NumberToken {
-			return false
-		}
-		m.chomp()
-		return true
-	case "percentage":
-		next := m.peek(1)
-		if next.Type != css.PercentageToken {
-			return false
-		}
-		m.chomp()
-		return true
-	case "dimension", "length", "angle", "time", "frequency", "resolution":
-		next := m.peek(1)
-		if next.Type != css.DimensionToken {
-			return false
-		}
-		m.chomp()
-		return true
+	case "integer", "number", "percentage", "dimension", "length", "angle", "time", "frequency", "resolution":
+		return m.matchNumeric(t)
 	case "ratio":
 		return m.match(ratioTerm)
 	default:
@@ -235,11 +312,25 @@ func (m *matcher) matchPropertyType(t PropertyType) bool {
 			Name:   string(t),
 			Values: m.result[c:],
 		})
+		return true
 	}
 	return false
 }
 
 func (m *matcher) matchNonTerminal(t NonTerminal) bool {
+	// Non-terminals may also be produced by functions, e.g. <transform-function>.
+	if m.peek(1).Type == css.FunctionToken {
+		so, sr, sc := m.offset, len(m.result), len(m.captures)
+		if f, ok := m.matchFunction(); ok && f.Type != nil && f.Type.Name == string(t) {
+			m.captures = append(m.captures, Capture{
+				Name:   string(t),
+				Values: m.result[sr:],
+			})
+			return true
+		}
+		m.offset, m.result, m.captures = so, m.result[:sr], m.captures[:sc]
+	}
+
 	if term, ok := m.context.NonTerminals[string(t)]; ok {
 		c := len(m.result)
 		if !m.match(term) {
@@ -273,6 +364,8 @@ func (m *matcher) matchLiteral(t Literal) bool {
 		expect = css.RightParenthesisToken
 	case ";":
 		expect = css.SemicolonToken
+	case ",":
+		expect = css.CommaToken
 	}
 
 	next := m.peek(1)
@@ -299,38 +392,55 @@ func (m *matcher) matchSeq(t *Seq) bool {
 	return true
 }
 
-func (m *matcher) matchAllOf(t *AllOf) bool {
-	pending := append([]Term{}, t.Operands...)
+// matchProgress matches the given term and returns true if the match consumed at least one
+// token. Matches that do not consume any tokens are undone.
+func (m *matcher) matchProgress(t Term) bool {
+	so, sr, sc := m.offset, len(m.result), len(m.captures)
+	if !m.match(t) {
+		return false
+	}
+	if m.offset == so {
+		m.result, m.captures = m.result[:sr], m.captures[:sc]
+		return false
+	}
+	return true
+}
 
-	for len(pending) != 0 {
-		if m.peek(1).Type == css.ErrorToken {
-			return false
-		}
+// matchUnordered matches each of the given operands at most once, in any order. It returns
+// the operands that were not matched.
+func (m *matcher) matchUnordered(operands []Term) []Term {
+	pending := append([]Term{}, operands...)
 
-		any := false
+	for len(pending) != 0 {
+		progress := false
 		for i := range pending {
-			if m.match(pending[i]) {
-				pending, any = append(pending[:i], pending[i+1:]...), true
+			if m.matchProgress(pending[i]) {
+				pending, progress = append(pending[:i], pending[i+1:]...), true
 				break
 			}
 		}
-		if !any {
-			return false
+		if !progress {
+			break
 		}
 	}
 
-	return true
+	return pending
 }
 
-func (m *matcher) matchAnyOf(t *AnyOf) bool {
-	if !m.matchOneOf(t.Operands) {
-		return false
-	}
-	for m.matchOneOf(t.Operands) {
+func (m *matcher) matchAllOf(t *AllOf) bool {
+	// Any operands that did not consume input must match the empty string.
+	for _, t := range m.matchUnordered(t.Operands) {
+		if !m.match(t) {
+			return false
+		}
 	}
 	return true
 }
 
+func (m *matcher) matchAnyOf(t *AnyOf) bool {
+	return len(m.matchUnordered(t.Operands)) < len(t.Operands)
+}
+
 func (m *matcher) matchOneOf(operands []Term) bool {
 	for _, t := range operands {
 		if m.match(t) {
@@ -356,7 +466,7 @@ func (m *matcher) matchGroup(t *Group) bool {
 }
 
 func (m *matcher) matchZeroOrMore(t *ZeroOrMore) bool {
-	for m.match(t.Operand) {
+	for m.matchProgress(t.Operand) {
 	}
 	return true
 }
@@ -365,7 +475,7 @@ func (m *matcher) matchOneOrMore(t *OneOrMore) bool {
 	if !m.match(t.Operand) {
 		return false
 	}
-	for m.match(t.Operand) {
+	for m.matchProgress(t.Operand) {
 	}
 	return true
 }
@@ -382,13 +492,15 @@ func (m *matcher) matchRepeat(t *Repeat) bool {
 		if next.Type == css.ErrorToken {
 			break
 		}
+		so, sr, sc := m.offset, len(m.result), len(m.captures)
 		if n > 0 && t.Commas {
 			if next.Type != css.CommaToken {
-				return false
+				break
 			}
 			m.chomp()
 		}
-		if !m.match(t.Operand) {
+		if !m.matchProgress(t.Operand) {
+			m.offset, m.result, m.captures = so, m.result[:sr], m.captures[:sc]
 			break
 		}
 		n++
@@ -404,13 +516,14 @@ func (m *matcher) matchFunction() (*Function, bool) {
 	if !ok {
 		return nil, false
 	}
+	sr, sc := len(m.result), len(m.captures)
 
 	// Consume the function token.
 	m.chomp()
 
 	for i, t := range fn.Params {
 		if !m.match(t) {
-			m.offset = s
+			m.offset, m.result, m.captures = s, m.result[:sr], m.captures[:sc]
 			return nil, false
 		}
 
@@ -422,7 +535,7 @@ func (m *matcher) matchFunction() (*Function, bool) {
 	}
 
 	if m.peek(1).Type != css.RightParenthesisToken {
-		m.offset = s
+		m.offset, m.result, m.captures = s, m.result[:sr], m.captures[:sc]
 		return nil, false
 	}
 
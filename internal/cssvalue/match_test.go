@@ -18,6 +18,9 @@ func TestMatch(t *testing.T) {
 	}
 
 	ctx := Context{
+		Properties: map[string]Term{
+			"padding-top": lengthPercentage,
+		},
 		NonTerminals: map[string]Term{
 			"family-name": &OneOf{
 				Operands: []Term{
@@ -63,6 +66,10 @@ func TestMatch(t *testing.T) {
 		{name: "font-family", input: "[ <family-name> | <generic-family> ]#", value: `"Gill Sans", Futura, sans-serif`},
 		{name: "border-width", input: "[ <length> | thick | medium | thin ]{1,4}", value: "2px medium 4px"},
 		{name: "box-shadow", input: "[ inset? && <length>{2,4} && <color>? ]# | none", value: "3px 3px rgba(50%, 50%, 50%, 50%), lemonchiffon 0 0 4px inset"},
+		{name: "margin", input: "<'padding-top'>{1,4}", value: "5% 2px"},
+		{name: "viewBox", input: "<number> ,? <number> ,? <number> ,? <number>", value: "0, 0 10,20"},
+		{name: "rotate", input: "<angle>", value: "1.5turn"},
+		{name: "line-height", input: "<number [0,∞]>", value: "1e1"},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -75,6 +82,42 @@ func TestMatch(t *testing.T) {
 	}
 }
 
+func TestNoMatch(t *testing.T) {
+	ctx := Context{
+		Properties: map[string]Term{
+			"padding-top": &OneOf{
+				Operands: []Term{
+					&BasicType{Name: "length"},
+					&BasicType{Name: "percentage"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		value string
+	}{
+		{name: "trailing", input: "<integer>", value: "3 4"},
+		{name: "integer", input: "<integer>", value: "3.5"},
+		{name: "units", input: "<length>", value: "5deg"},
+		{name: "unitless", input: "<length>", value: "5"},
+		{name: "range", input: "<number [0,1]>", value: "1.5"},
+		{name: "repeated", input: "underline || overline", value: "underline underline"},
+		{name: "margin", input: "<'padding-top'>{1,4}", value: "5% 2px 1px 1px 1px"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			term, err := ParseGrammar(strings.NewReader(c.input))
+			require.NoError(t, err)
+
+			captures := Match(&ctx, term, strings.NewReader(c.value))
+			assert.Nil(t, captures)
+		})
+	}
+}
+
 func TestCaptures(t *testing.T) {
 	ctx := Context{
 		NonTerminals: map[string]Term{
@@ -272,7 +272,7 @@ func parseMultipliable(l *lexer) (Term, error) {
 		if err := l.lex(); err != nil && err != io.EOF {
 			return nil, err
 		}
-		return Literal(string([]rune{l.token})), nil
+		return Literal(","), nil
 	case 0:
 		return nil, nil
 	default:
@@ -391,6 +391,18 @@ func parseType(l *lexer) (Term, error) {
 	}
 }
 
+// parseRangeBound parses a bound of a bracketed range. Bounds may be infinite.
+func parseRangeBound(v string) (float64, error) {
+	switch v {
+	case "∞", "+∞":
+		return math.Inf(1), nil
+	case "-∞":
+		return math.Inf(-1), nil
+	default:
+		return strconv.ParseFloat(v, 64)
+	}
+}
+
 func parseRange(l *lexer) (*Range, error) {
 	// skip '['
 	if err := l.lex(); err != nil {
@@ -400,7 +412,7 @@ func parseRange(l *lexer) (*Range, error) {
 	if l.token != numberToken {
 		return nil, errors.New("expected a number")
 	}
-	min, err := strconv.ParseFloat(l.value.String(), 64)
+	min, err := parseRangeBound(l.value.String())
 	if err != nil {
 		return nil, err
 	}
@@ -418,7 +430,7 @@ func parseRange(l *lexer) (*Range, error) {
 	if l.token != numberToken {
 		return nil, errors.New("expected a number")
 	}
-	max, err := strconv.ParseFloat(l.value.String(), 64)
+	max, err := parseRangeBound(l.value.String())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,208 @@
+package svg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pgavlin/svg2/internal/cssvalue"
+)
+
+// propertyGrammars maps the name of each supported property to the grammar for its values. The
+// grammars are written using the CSS value definition syntax, and are taken from the SVG and
+// CSS specifications.
+//
+// Note that in contrast to CSS, SVG permits lengths to be specified using unitless numbers, so
+// <length> is generally written as <length> | <number>.
+var propertyGrammars = map[string]string{
+	"alignment-baseline":          "auto | baseline | before-edge | text-before-edge | middle | central | after-edge | text-after-edge | ideographic | alphabetic | hanging | mathematical | top | center | bottom",
+	"baseline-shift":              "<length-percentage> | sub | super | baseline",
+	"clip-path":                   "none | <url> | [ <basic-shape> || <geometry-box> ]",
+	"clip-rule":                   "nonzero | evenodd",
+	"color":                       "<color>",
+	"color-interpolation":         "auto | sRGB | linearRGB",
+	"color-interpolation-filters": "auto | sRGB | linearRGB",
+	"color-rendering":             "auto | optimizeSpeed | optimizeQuality",
+	"cursor":                      "[ <url> , ]* [ auto | crosshair | default | pointer | move | e-resize | ne-resize | nw-resize | n-resize | se-resize | sw-resize | s-resize | w-resize | text | wait | help ]",
+	"direction":                   "ltr | rtl",
+	"display":                     "inline | block | list-item | run-in | compact | table | inline-table | table-row-group | table-header-group | table-footer-group | table-row | table-column-group | table-column | table-cell | table-caption | inline-block | flex | inline-flex | grid | inline-grid | contents | none",
+	"dominant-baseline":           "auto | use-script | no-change | reset-size | ideographic | alphabetic | hanging | mathematical | central | middle | text-after-edge | text-before-edge | text-bottom | text-top",
+	"fill":                        "<paint>",
+	"fill-opacity":                "<alpha-value>",
+	"fill-rule":                   "nonzero | evenodd",
+	"filter":                      "none | <url>+",
+	"flood-color":                 "<color>",
+	"flood-opacity":               "<alpha-value>",
+	"font-family":                 "[ <generic-family> | <family-name> ]#",
+	"font-size":                   "<absolute-size> | <relative-size> | <length-percentage>",
+	"font-size-adjust":            "none | <number>",
+	"font-stretch":                "normal | ultra-condensed | extra-condensed | condensed | semi-condensed | semi-expanded | expanded | extra-expanded | ultra-expanded",
+	"font-style":                  "normal | italic | oblique",
+	"font-variant":                "normal | small-caps",
+	"font-weight":                 "normal | bold | bolder | lighter | <number [1,1000]>",
+	"image-rendering":             "auto | optimizeSpeed | optimizeQuality | smooth | high-quality | crisp-edges | pixelated",
+	"letter-spacing":              "normal | <length> | <number>",
+	"isolation":                   "auto | isolate",
+	"lighting-color":              "<color>",
+	"marker-end":                  "none | <url>",
+	"marker-mid":                  "none | <url>",
+	"marker-start":                "none | <url>",
+	"mask":                        "none | <url>",
+	"mask-type":                   "luminance | alpha",
+	"mix-blend-mode":              "<blend-mode>",
+	"opacity":                     "<alpha-value>",
+	"overflow":                    "visible | hidden | scroll | auto",
+	"paint-order":                 "normal | [ fill || stroke || markers ]",
+	"pointer-events":              "bounding-box | visiblePainted | visibleFill | visibleStroke | visible | painted | fill | stroke | all | none",
+	"shape-rendering":             "auto | optimizeSpeed | crispEdges | geometricPrecision",
+	"stop-color":                  "<color>",
+	"stop-opacity":                "<alpha-value>",
+	"stroke":                      "<paint>",
+	"stroke-dasharray":            "none | [ <length-percentage> ,? ]+",
+	"stroke-dashoffset":           "<length-percentage>",
+	"stroke-linecap":              "butt | round | square",
+	"stroke-linejoin":             "miter | miter-clip | round | bevel | arcs",
+	"stroke-miterlimit":           "<number [1,∞]>",
+	"stroke-opacity":              "<alpha-value>",
+	"stroke-width":                "<length-percentage>",
+	"text-anchor":                 "start | middle | end",
+	"text-decoration":             "none | [ underline || overline || line-through || blink ]",
+	"text-overflow":               "clip | ellipsis",
+	"text-rendering":              "auto | optimizeSpeed | optimizeLegibility | geometricPrecision",
+	"transform":                   "none | <transform-list>",
+	"unicode-bidi":                "normal | embed | isolate | bidi-override | isolate-override | plaintext",
+	"vector-effect":               "none | non-scaling-stroke | non-scaling-size | non-rotation | fixed-position",
+	"visibility":                  "visible | hidden | collapse",
+	"white-space":                 "normal | pre | nowrap | pre-wrap | break-spaces | pre-line",
+	"word-spacing":                "normal | <length> | <number>",
+	"writing-mode":                "horizontal-tb | vertical-rl | vertical-lr | lr | lr-tb | rl | rl-tb | tb | tb-rl",
+}
+
+// nonTerminalGrammars maps the name of each non-terminal that may be referenced by a property
+// or value grammar to its definition.
+var nonTerminalGrammars = map[string]string{
+	"absolute-size":     "xx-small | x-small | small | medium | large | x-large | xx-large | xxx-large",
+	"alpha-value":       "<number> | <percentage>",
+	"blend-mode":        "normal | multiply | screen | overlay | darken | lighten | color-dodge | color-burn | hard-light | soft-light | difference | exclusion | hue | saturation | color | luminosity",
+	"family-name":       "<string> | <custom-ident>+",
+	"geometry-box":      "fill-box | stroke-box | view-box | margin-box | border-box | padding-box | content-box",
+	"generic-family":    "serif | sans-serif | cursive | fantasy | monospace | system-ui",
+	"length-percentage": "<length> | <number> | <percentage>",
+	"paint":             "none | context-fill | context-stroke | <url> [ none | <color> ]? | <color>",
+	"position":          "[ left | center | right | top | bottom | <length-percentage> ] [ left | center | right | top | bottom | <length-percentage> ]?",
+	"relative-size":     "larger | smaller",
+	"shape-radius":      "<length-percentage> | closest-side | farthest-side",
+	"transform-list":    "<transform-function> [ ,? <transform-function> ]*",
+}
+
+// functionGrammars maps the name of each supported functional notation to the grammars for
+// its parameters and the type of its result.
+var functionGrammars = map[string]struct {
+	params []string
+	result string
+}{
+	"rgb":  {[]string{"<number> | <percentage>", "<number> | <percentage>", "<number> | <percentage>"}, "color"},
+	"rgba": {[]string{"<number> | <percentage>", "<number> | <percentage>", "<number> | <percentage>", "<alpha-value>"}, "color"},
+	"hsl":  {[]string{"<number> | <angle>", "<percentage>", "<percentage>"}, "color"},
+	"hsla": {[]string{"<number> | <angle>", "<percentage>", "<percentage>", "<alpha-value>"}, "color"},
+
+	"inset":   {[]string{"<length-percentage>{1,4} [ round <length-percentage>{1,4} ]?"}, "basic-shape"},
+	"circle":  {[]string{"<shape-radius>? [ at <position> ]?"}, "basic-shape"},
+	"ellipse": {[]string{"[ <shape-radius>{2} ]? [ at <position> ]?"}, "basic-shape"},
+	"polygon": {[]string{"[ nonzero | evenodd ]?", "[ <length-percentage> <length-percentage> ]#"}, "basic-shape"},
+
+	"matrix":    {[]string{"<number>", "<number>", "<number>", "<number>", "<number>", "<number>"}, "transform-function"},
+	"translate": {[]string{"<length> | <number>", "[ <length> | <number> ]?"}, "transform-function"},
+	"scale":     {[]string{"<number>", "<number>?"}, "transform-function"},
+	"rotate":    {[]string{"<angle> | <number>", "[ <number> ,? <number> ]?"}, "transform-function"},
+	"skewX":     {[]string{"<angle> | <number>"}, "transform-function"},
+	"skewY":     {[]string{"<angle> | <number>"}, "transform-function"},
+}
+
+func mustParseGrammar(grammar string) cssvalue.Term {
+	term, err := cssvalue.ParseGrammar(strings.NewReader(grammar))
+	if err != nil {
+		panic(fmt.Errorf("invalid grammar %q: %w", grammar, err))
+	}
+	return term
+}
+
+var propertyContext = func() *cssvalue.Context {
+	ctx := &cssvalue.Context{
+		Properties:   map[string]cssvalue.Term{},
+		NonTerminals: map[string]cssvalue.Term{},
+		Functions:    map[string]*cssvalue.Function{},
+	}
+	for name, grammar := range propertyGrammars {
+		ctx.Properties[name] = mustParseGrammar(grammar)
+	}
+	for name, grammar := range nonTerminalGrammars {
+		ctx.NonTerminals[name] = mustParseGrammar(grammar)
+	}
+	for name, fn := range functionGrammars {
+		params := make([]cssvalue.Term, len(fn.params))
+		for i, p := range fn.params {
+			params[i] = mustParseGrammar(p)
+		}
+		ctx.Functions[name] = &cssvalue.Function{
+			Params: params,
+			Type:   &cssvalue.BasicType{Name: fn.result},
+		}
+	}
+	return ctx
+}()
+
+var valueGrammars sync.Map // map[string]cssvalue.Term
+
+// matchValue matches a value against the given grammar. If the value matches, matchValue
+// returns the captures produced by the match; the last capture holds all of the value's
+// tokens, excluding whitespace.
+func matchValue(grammar string, text []byte) ([]cssvalue.Capture, error) {
+	term, ok := valueGrammars.Load(grammar)
+	if !ok {
+		term, _ = valueGrammars.LoadOrStore(grammar, mustParseGrammar(grammar))
+	}
+
+	captures := cssvalue.Match(propertyContext, term.(cssvalue.Term), strings.NewReader(string(text)))
+	if len(captures) == 0 {
+		return nil, fmt.Errorf("invalid value %q: expected %v", text, grammar)
+	}
+	return captures, nil
+}
+
+// matchTokens matches a value against the given grammar and returns the value's tokens,
+// excluding whitespace.
+func matchTokens(grammar string, text []byte) ([]cssToken, error) {
+	captures, err := matchValue(grammar, text)
+	if err != nil {
+		return nil, err
+	}
+	return captures[len(captures)-1].Values, nil
+}
+
+// validateProperty returns an error if the given value is not valid for the named property.
+// Values for unknown properties are not validated.
+func validateProperty(name, value string) error {
+	term, ok := propertyContext.Properties[name]
+	if !ok || strings.TrimSpace(value) == "inherit" {
+		return nil
+	}
+	if cssvalue.Match(propertyContext, term, strings.NewReader(value)) == nil {
+		return fmt.Errorf("invalid value %q for property %v", value, name)
+	}
+	return nil
+}
+
+// validProperties returns the given list of attributes less any presentation attributes with
+// invalid values. As in CSS, invalid property values are ignored.
+func validProperties(attrs []xml.Attr) []xml.Attr {
+	valid := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Name.Space == "" && validateProperty(attr.Name.Local, attr.Value) != nil {
+			continue
+		}
+		valid = append(valid, attr)
+	}
+	return valid
+}
@@ -0,0 +1,165 @@
+package svg
+
+import (
+	"encoding/xml"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProperty(t *testing.T) {
+	cases := []struct {
+		property string
+		value    string
+		valid    bool
+	}{
+		{"fill", "none", true},
+		{"fill", "url(#g) red", true},
+		{"fill", "rgb(255, 0, 0)", true},
+		{"fill", "hsla(120deg, 50%, 50%, 0.5)", true},
+		{"fill", "rgb(255, 0)", false},
+		{"fill", "red blue", false},
+		{"stroke-width", "5", true},
+		{"stroke-width", "5px", true},
+		{"stroke-width", "5deg", false},
+		{"stroke-linecap", "round", true},
+		{"stroke-linecap", "pointy", false},
+		{"stroke-miterlimit", "0.5", false},
+		{"font-family", `"Gill Sans", Futura, sans-serif`, true},
+		{"font-family", "Times New Roman, serif", true},
+		{"font-weight", "bold", true},
+		{"font-weight", "1500", false},
+		{"text-decoration", "underline overline", true},
+		{"text-decoration", "underline underline", false},
+		{"transform", "translate(10, 20) rotate(45 5 5)", true},
+		{"transform", "translate(10, 20, 30)", false},
+		{"opacity", "50%", true},
+		{"opacity", "inherit", true},
+	}
+	for _, c := range cases {
+		t.Run(c.property+": "+c.value, func(t *testing.T) {
+			err := validateProperty(c.property, c.value)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestInvalidProperties(t *testing.T) {
+	cases := []struct {
+		attr  string
+		check func(t *testing.T, a *ElementAttributes)
+	}{
+		{`transform=""`, func(t *testing.T, a *ElementAttributes) { assert.Nil(t, a.Transform) }},
+		{`fill=""`, func(t *testing.T, a *ElementAttributes) { assert.Nil(t, a.Fill) }},
+		{`stroke-miterlimit="0.5"`, func(t *testing.T, a *ElementAttributes) { assert.Nil(t, a.StrokeMiterlimit) }},
+		{`filter="blur(2px)"`, func(t *testing.T, a *ElementAttributes) { assert.Nil(t, a.Filter) }},
+	}
+	for _, c := range cases {
+		t.Run(c.attr, func(t *testing.T) {
+			doc := `<svg xmlns="http://www.w3.org/2000/svg" ` + c.attr + `><rect ` + c.attr + ` stroke="red" width="10" height="10"/></svg>`
+
+			var svg SVG
+			require.NoError(t, xml.Unmarshal([]byte(doc), &svg))
+			c.check(t, &svg.ElementAttributes)
+
+			require.Len(t, svg.Children, 1)
+			rect, ok := svg.Children[0].X.(*Rect)
+			require.True(t, ok)
+			c.check(t, &rect.ElementAttributes)
+			assert.NotNil(t, rect.Stroke)
+		})
+	}
+}
+
+func TestColor(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected color.NRGBA
+	}{
+		{"#f00", color.NRGBA{R: 255, A: 255}},
+		{"#00ff0080", color.NRGBA{G: 255, A: 128}},
+		{"Blue", color.NRGBA{B: 255, A: 255}},
+		{"rgb(100%, 50%, 0)", color.NRGBA{R: 255, G: 128, A: 255}},
+		{"rgba(0, 0, 255, 0.5)", color.NRGBA{B: 255, A: 128}},
+		{"hsl(120, 100%, 50%)", color.NRGBA{G: 255, A: 255}},
+		{"hsl(0.5turn, 100%, 50%)", color.NRGBA{G: 255, B: 255, A: 255}},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			var v Color
+			require.NoError(t, v.UnmarshalText([]byte(c.value)))
+			assert.Equal(t, c.expected, color.NRGBAModel.Convert(v.Value))
+		})
+	}
+}
+
+func TestClipSource(t *testing.T) {
+	pct := func(p float64) LengthPercentage { return LengthPercentage{Percentage: p} }
+	px := func(v float64) LengthPercentageIdent {
+		return LengthPercentageIdent{LengthPercentage: LengthPercentage{Length: Length{Value: v}}}
+	}
+
+	cases := []struct {
+		value    string
+		expected ClipSource
+	}{
+		{"none", ClipSource{}},
+		{"url(#clip)", ClipSource{URL: "#clip"}},
+		{"view-box", ClipSource{Box: "view-box"}},
+		{"circle(10 at top left)", ClipSource{Shape: &BasicShape{
+			Function: "circle",
+			Args:     []LengthPercentageIdent{px(10)},
+			Position: &[2]LengthPercentage{pct(0), pct(0)},
+		}}},
+		{"ellipse(closest-side 5 at bottom) fill-box", ClipSource{Box: "fill-box", Shape: &BasicShape{
+			Function: "ellipse",
+			Args:     []LengthPercentageIdent{{Ident: "closest-side"}, px(5)},
+			Position: &[2]LengthPercentage{pct(0.5), pct(1)},
+		}}},
+		{"inset(1 2 round 3)", ClipSource{Shape: &BasicShape{
+			Function: "inset",
+			Args:     []LengthPercentageIdent{px(1), px(2), px(1), px(2)},
+			Radius:   &LengthPercentage{Length: Length{Value: 3}},
+		}}},
+		{"polygon(evenodd, 0 0, 1 0, 1 1)", ClipSource{Shape: &BasicShape{
+			Function: "polygon",
+			Args:     []LengthPercentageIdent{px(0), px(0), px(1), px(0), px(1), px(1)},
+			FillRule: "evenodd",
+		}}},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			var v ClipSource
+			require.NoError(t, v.UnmarshalText([]byte(c.value)))
+			assert.Equal(t, c.expected, v)
+		})
+	}
+}
+
+func TestDashArray(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected DashArray
+	}{
+		{"none", DashArray{}},
+		{"5", DashArray{Values: []LengthPercentage{{Length: Length{Value: 5}}}}},
+		{"5, 10% 2px", DashArray{Values: []LengthPercentage{
+			{Length: Length{Value: 5}},
+			{Percentage: 0.1},
+			{Length: Length{Value: 2, Units: "px"}},
+		}}},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			var v DashArray
+			require.NoError(t, v.UnmarshalText([]byte(c.value)))
+			assert.Equal(t, c.expected, v)
+		})
+	}
+}
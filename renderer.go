@@ -3,35 +3,92 @@ package svg
 import (
 	"errors"
 	"fmt"
+	"image"
 	"image/color"
 	"math"
 
 	"github.com/fogleman/gg"
-	"golang.org/x/image/font"
 )
 
+// Options controls the rendering of an SVG document.
+type Options struct {
+	// DPI is the resolution used to convert absolute lengths, such as inches and points, into
+	// user units. If DPI is zero, DefaultDPI is used.
+	DPI float64
+}
+
+func (o *Options) dpi() float64 {
+	if o == nil || o.DPI == 0 {
+		return DefaultDPI
+	}
+	return o.DPI
+}
+
+// documentSize returns the size of an SVG document's viewport. If the document does not
+// specify a width or height, the missing dimension is derived from the document's viewBox,
+// preserving its aspect ratio. Documents that specify neither default to 1024x1024.
+func documentSize(svg *SVG, dpi float64) (width, height float64) {
+	absolute := func(blp BoxLengthPercentage) float64 {
+		if blp.Value != "" || blp.Percentage != 0 {
+			return 0
+		}
+		if v, ok := absoluteLength(blp.Length, dpi); ok {
+			return v
+		}
+		// Font-relative lengths are resolved against the initial font size.
+		if blp.Length.Units == "em" || blp.Length.Units == "rem" {
+			return blp.Length.Value * mediumFontSize
+		}
+		return 0
+	}
+
+	width, height = absolute(svg.Width), absolute(svg.Height)
+	if vb := svg.ViewBox; vb != nil && vb.Width != 0 && vb.Height != 0 {
+		switch {
+		case width == 0 && height == 0:
+			width, height = vb.Width, vb.Height
+		case width == 0:
+			width = height * vb.Width / vb.Height
+		case height == 0:
+			height = width * vb.Height / vb.Width
+		}
+	}
+
+	if width == 0 || height == 0 {
+		return 1024, 1024
+	}
+	return width, height
+}
+
 // NewContext creates a new render context for an SVG document.
 func NewContext(svg *SVG) *gg.Context {
-	if svg.Width.Length.Value != 0 && svg.Height.Length.Value != 0 {
-		return gg.NewContext(int(svg.Width.Length.Value), int(svg.Height.Length.Value))
-	}
-	return gg.NewContext(1024, 1024)
+	return NewContextWithOptions(svg, 1, nil)
 }
 
 // NewScaledContext creates a new render context for an SVG document with the given scaling factor.
 func NewScaledContext(svg *SVG, scale float64) *gg.Context {
-	width, height := 1024, 1024
-	if svg.Width.Length.Value != 0 && svg.Height.Length.Value != 0 {
-		width, height = int(svg.Width.Length.Value), int(svg.Height.Length.Value)
-	}
+	return NewContextWithOptions(svg, scale, nil)
+}
+
+// NewContextWithOptions creates a new render context for an SVG document with the given
+// scaling factor and rendering options.
+func NewContextWithOptions(svg *SVG, scale float64, opts *Options) *gg.Context {
+	width, height := documentSize(svg, opts.dpi())
 
-	ctx := gg.NewContext(int(float64(width)*scale), int(float64(height)*scale))
-	ctx.Scale(scale, scale)
+	ctx := gg.NewContext(int(width*scale), int(height*scale))
+	if scale != 1 {
+		ctx.Scale(scale, scale)
+	}
 	return ctx
 }
 
 // Render renders an SVG document to the given context.
 func Render(ctx *gg.Context, svg *SVG) error {
+	return RenderWithOptions(ctx, svg, nil)
+}
+
+// RenderWithOptions renders an SVG document to the given context using the given options.
+func RenderWithOptions(ctx *gg.Context, svg *SVG, opts *Options) error {
 	// Graphics are painted and composited in rendering-tree order, subject to re-ordering based on the paint-order property. Note that elements that have no visual paint may still be in the rendering tree.
 	//
 	// shadow DOM elements, such as those generated by ‘use’ elements or by cross-references between paint servers;
@@ -63,12 +120,17 @@ func Render(ctx *gg.Context, svg *SVG) error {
 	//   2. The contents of the group that are graphics elements or ‘g’ elements are rendered in order, onto the initial backdrop. The group transforms are applied to each element as they are rendered.
 	//
 
+	// Apply the document's style sheets and inline styles.
+	svg = applyStyles(svg)
+
 	// Collect elements by ID.
 	//
 	// TODO: duplicate IDs
 	r := renderer{
 		elements: map[string]Element{},
 		fonts:    map[string]*fontFamily{},
+		dpi:      opts.dpi(),
+		parents:  parents(svg),
 	}
 	walk(svg, func(e Element) {
 		if id := e.id(); id != "" {
@@ -82,15 +144,130 @@ func Render(ctx *gg.Context, svg *SVG) error {
 			Fill:       &Paint{Color: color.Black},
 			Stroke:     &Paint{Color: color.Transparent},
 			FontFamily: &FontFamily{Values: []string{"sans-serif"}},
-			FontSize:   &LengthPercentageNumberIdent{LengthPercentageNumber: LengthPercentageNumber{Number: 12}},
+			FontSize:   &LengthPercentageNumberIdent{Ident: "medium"},
 			FontStyle:  "normal",
 			FontWeight: &NumberIdent{Ident: "normal"},
 		},
 	}
 
-	r.push(root, float64(ctx.Width()), float64(ctx.Height()))
+	width, height := documentSize(svg, r.dpi)
+	if vb := svg.ViewBox; vb != nil {
+		if vb.Width == 0 || vb.Height == 0 {
+			// A zero-sized view box disables rendering of the element.
+			return nil
+		}
+
+		ctx.Push()
+		defer ctx.Pop()
+
+		applyViewBox(ctx, vb, svg.PreserveAspectRatio, 0, 0, width, height)
+		width, height = vb.Width, vb.Height
+	}
+
+	r.push(root, width, height)
+	r.push(svg, width, height)
+
+	return r.renderCompositingGroup(ctx, svg.Children)
+}
 
-	return r.renderCompositingGroup(ctx, false, svg.Children)
+// applyViewBox establishes a new user coordinate system that maps the given view box onto
+// the viewport at (x, y) with the given width and height according to the given
+// preserveAspectRatio value.
+//
+// See https://www.w3.org/TR/SVG2/coords.html#ComputingAViewportsTransform for details.
+func applyViewBox(ctx *gg.Context, vb *ViewBox, par PreserveAspectRatio, x, y, width, height float64) {
+	translateX, translateY, scaleX, scaleY := viewBoxTransform(vb, par, x, y, width, height)
+	ctx.Translate(translateX, translateY)
+	ctx.Scale(scaleX, scaleY)
+}
+
+// viewBoxTransform returns the translation and scale that map the given view box onto the
+// viewport at (x, y) with the given width and height. The scale is applied first.
+func viewBoxTransform(vb *ViewBox, par PreserveAspectRatio, x, y, width, height float64) (translateX, translateY, scaleX, scaleY float64) {
+	scaleX, scaleY = width/vb.Width, height/vb.Height
+
+	align := par.Align
+	if align == "" {
+		align = "xMidYMid"
+	}
+	if align != "none" {
+		if par.MeetOrSlice == "slice" {
+			scaleX = math.Max(scaleX, scaleY)
+		} else {
+			scaleX = math.Min(scaleX, scaleY)
+		}
+		scaleY = scaleX
+	}
+
+	translateX, translateY = x-vb.MinX*scaleX, y-vb.MinY*scaleY
+	if align != "none" {
+		switch align[:4] {
+		case "xMid":
+			translateX += (width - vb.Width*scaleX) / 2
+		case "xMax":
+			translateX += width - vb.Width*scaleX
+		}
+		switch align[4:] {
+		case "YMid":
+			translateY += (height - vb.Height*scaleY) / 2
+		case "YMax":
+			translateY += height - vb.Height*scaleY
+		}
+	}
+	return translateX, translateY, scaleX, scaleY
+}
+
+// currentMatrix returns the context's current transformation matrix.
+func currentMatrix(ctx *gg.Context) gg.Matrix {
+	x0, y0 := ctx.TransformPoint(0, 0)
+	x1, y1 := ctx.TransformPoint(1, 0)
+	x2, y2 := ctx.TransformPoint(0, 1)
+	return gg.Matrix{XX: x1 - x0, YX: y1 - y0, XY: x2 - x0, YY: y2 - y0, X0: x0, Y0: y0}
+}
+
+// invertMatrix returns the inverse of the given matrix. If the matrix is not invertible,
+// invertMatrix returns false.
+func invertMatrix(m gg.Matrix) (gg.Matrix, bool) {
+	det := m.XX*m.YY - m.XY*m.YX
+	if det == 0 {
+		return gg.Matrix{}, false
+	}
+	return gg.Matrix{
+		XX: m.YY / det,
+		YX: -m.YX / det,
+		XY: -m.XY / det,
+		YY: m.XX / det,
+		X0: (m.XY*m.Y0 - m.YY*m.X0) / det,
+		Y0: (m.YX*m.X0 - m.XX*m.Y0) / det,
+	}, true
+}
+
+// applyMatrix concatenates the given matrix onto the context's current transformation
+// matrix. gg does not allow its matrix to be set directly, so the matrix is decomposed into
+// a translation, a rotation, a scale, and a shear. If the matrix is not invertible, the
+// context is left unchanged and applyMatrix returns false.
+func applyMatrix(ctx *gg.Context, m gg.Matrix) bool {
+	det := m.XX*m.YY - m.XY*m.YX
+	if det == 0 {
+		return false
+	}
+
+	sx := math.Hypot(m.XX, m.YX)
+	ctx.Translate(m.X0, m.Y0)
+	ctx.Rotate(math.Atan2(m.YX, m.XX))
+	ctx.Scale(sx, det/sx)
+	ctx.Shear((m.XX*m.XY+m.YX*m.YY)/(sx*sx), 0)
+	return true
+}
+
+// transform applies an element's transform attribute to the context. If the transform is
+// not invertible, the element must not be rendered, and transform returns false.
+func (r *renderer) transform(ctx *gg.Context, e Element) bool {
+	tl := e.attrs().Transform
+	if tl == nil {
+		return true
+	}
+	return applyMatrix(ctx, tl.Matrix())
 }
 
 type element struct {
@@ -119,6 +296,25 @@ func (r *renderer) height() float64 {
 	return r.top().height
 }
 
+// ancestorStack returns an element stack that holds the root elements followed by the
+// ancestors of the given element in the document. Elements that are rendered by reference,
+// such as the contents of a pattern, inherit properties from their ancestors rather than from
+// the element that references them.
+func (r *renderer) ancestorStack(e Element) []*element {
+	var ancestors []Element
+	for p := r.parents[e]; p != nil; p = r.parents[p] {
+		ancestors = append(ancestors, p)
+	}
+
+	viewport := r.stack[1]
+	stack := make([]*element, 2, len(ancestors)+3)
+	copy(stack, r.stack[:2])
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		stack = append(stack, &element{Element: ancestors[i], width: viewport.width, height: viewport.height})
+	}
+	return stack
+}
+
 func (r *renderer) diag() float64 {
 	w, h := r.width(), r.height()
 	return math.Sqrt(w*w+h*h) / math.Sqrt2
@@ -128,6 +324,29 @@ type renderer struct {
 	elements map[string]Element
 	fonts    map[string]*fontFamily
 	stack    []*element
+
+	// parents maps each element in the document to its parent.
+	parents map[Element]Element
+
+	// dpi is the resolution used to convert absolute lengths into user units.
+	dpi float64
+
+	// clips is the stack of active clip masks.
+	clips []*image.Alpha
+
+	// patterns is the set of patterns whose tiles are being rendered.
+	patterns map[*Pattern]bool
+
+	// clipPaths is the set of clipPaths whose contents are being rendered, and clipping is
+	// true while their contents are being rendered.
+	clipPaths map[*ClipPath]bool
+	clipping  bool
+
+	// masks is the set of masks whose contents are being rendered.
+	masks map[*Mask]bool
+
+	// markers is the set of markers whose contents are being rendered.
+	markers map[*Marker]bool
 }
 
 func (r *renderer) computeNumberPercentage(parent float64, np *NumberPercentage) float64 {
@@ -144,10 +363,7 @@ func (r *renderer) computeLengthPercentage(parent float64, lp LengthPercentage)
 	if lp.Percentage != 0 {
 		return lp.Percentage * parent
 	}
-	if lp.Length.Units != "" {
-		panic(errors.New("NYI: units"))
-	}
-	return lp.Length.Value
+	return r.computeLength(lp.Length)
 }
 
 func (r *renderer) computeLengthPercentageNumber(parent float64, lp LengthPercentageNumber) float64 {
@@ -171,51 +387,93 @@ func (r *renderer) computeBoxLengthPercentage(parent, auto float64, blp *BoxLeng
 	return parent
 }
 
-func (r *renderer) computePattern(e Element, patternOpacity float64) (gg.Pattern, error) {
-	switch e := e.(type) {
+// computePattern computes a gg.Pattern for the given paint server element. bounds is the
+// bounding box of the element being painted.
+func (r *renderer) computePattern(ctx *gg.Context, e Element, patternOpacity float64, bounds boundingBox) (gg.Pattern, error) {
+	switch e := r.resolveGradient(e).(type) {
 	case *LinearGradient:
-		x1, y1 := r.computeLengthPercentage(r.width(), e.X1), r.computeLengthPercentage(r.height(), e.Y1)
-		x2, y2 := r.computeLengthPercentage(r.width(), e.X2), r.computeLengthPercentage(r.height(), e.Y2)
-		gradient := gg.NewLinearGradient(x1, y1, x2, y2)
+		space, width, height, ok := r.gradientSpace(ctx, &e.Gradient, bounds)
+		if !ok {
+			return gg.NewSolidPattern(color.Transparent), nil
+		}
 
-		r.push(e, r.top().width, r.top().height)
-		defer r.pop()
+		x1, y1 := r.computeGradientCoordinate(width, 0, e.X1), r.computeGradientCoordinate(height, 0, e.Y1)
+		x2, y2 := r.computeGradientCoordinate(width, 1, e.X2), r.computeGradientCoordinate(height, 0, e.Y2)
 
-		dx, dy := x2-x1, y2-y1
-		sz := math.Sqrt(dx*dx + dy*dy)
+		stops := r.computeGradientStops(e.Stops, patternOpacity)
+		switch {
+		case len(stops) == 0:
+			return gg.NewSolidPattern(color.Transparent), nil
+		case len(stops) == 1 || x1 == x2 && y1 == y2:
+			return gg.NewSolidPattern(stops[len(stops)-1].color), nil
+		}
 
-		for _, s := range e.Stops {
-			offset := r.computeNumberPercentage(sz, &s.Offset)
+		inverse, ok := invertMatrix(space)
+		if !ok {
+			return gg.NewSolidPattern(color.Transparent), nil
+		}
 
-			if s.Color.Value == nil {
-				s.Color.Value = color.Black
-			}
-			sr, sg, sb, sa := s.Color.Value.RGBA()
+		return &linearGradient{
+			inverse: inverse,
+			x1:      x1,
+			y1:      y1,
+			x2:      x2,
+			y2:      y2,
+			spread:  e.SpreadMethod,
+			stops:   stops,
+		}, nil
+	case *RadialGradient:
+		space, width, height, ok := r.gradientSpace(ctx, &e.Gradient, bounds)
+		if !ok {
+			return gg.NewSolidPattern(color.Transparent), nil
+		}
+		diag := math.Sqrt((width*width + height*height) / 2)
 
-			opacity := r.computeNumberPercentage(1.0, &s.Opacity)
-			if sa == 0 {
-				opacity = 0
-			}
+		cx, cy := r.computeGradientCoordinate(width, 0.5, e.Cx), r.computeGradientCoordinate(height, 0.5, e.Cy)
+		radius := r.computeGradientCoordinate(diag, 0.5, e.R)
 
-			gradient.AddColorStop(offset, color.NRGBA{
-				R: byte(sr),
-				G: byte(sg),
-				B: byte(sb),
-				A: byte(opacity * patternOpacity * 255),
-			})
+		// The focal point defaults to the center of the end circle.
+		fx, fy := cx, cy
+		if e.Fx != nil {
+			fx = r.computeLengthPercentage(width, *e.Fx)
+		}
+		if e.Fy != nil {
+			fy = r.computeLengthPercentage(height, *e.Fy)
+		}
+		fr := r.computeGradientCoordinate(diag, 0, e.Fr)
+
+		stops := r.computeGradientStops(e.Stops, patternOpacity)
+		switch {
+		case len(stops) == 0:
+			return gg.NewSolidPattern(color.Transparent), nil
+		case len(stops) == 1 || radius <= 0:
+			return gg.NewSolidPattern(stops[len(stops)-1].color), nil
 		}
 
-		return gradient, nil
-	case *RadialGradient:
-		return nil, errors.New("NYI: radial gradients")
+		inverse, ok := invertMatrix(space)
+		if !ok {
+			return gg.NewSolidPattern(color.Transparent), nil
+		}
+
+		return &radialGradient{
+			inverse: inverse,
+			fx:      fx,
+			fy:      fy,
+			fr:      fr,
+			cx:      cx,
+			cy:      cy,
+			r:       radius,
+			spread:  e.SpreadMethod,
+			stops:   stops,
+		}, nil
 	case *Pattern:
-		return nil, errors.New("NYI: pattern")
+		return r.computeTilePattern(ctx, e, patternOpacity, bounds)
 	default:
 		return nil, errors.New("not a paint server element")
 	}
 }
 
-func (r *renderer) computePaint(p *Paint, opacity float64) (gg.Pattern, error) {
+func (r *renderer) computePaint(ctx *gg.Context, p *Paint, opacity float64, bounds boundingBox) (gg.Pattern, error) {
 	switch p.Context {
 	case "context-fill":
 		return nil, errors.New("NYI: context-fill")
@@ -224,13 +482,12 @@ func (r *renderer) computePaint(p *Paint, opacity float64) (gg.Pattern, error) {
 	}
 
 	if p.URL != "" {
-		if p.URL[0] != '#' {
-			return nil, errors.New("NYI: non-fragment URLs")
+		e, err := r.lookup(p.URL)
+		if err != nil {
+			return nil, err
 		}
-		id := p.URL[1:]
-		e, ok := r.elements[id]
-		if ok {
-			if p, err := r.computePattern(e, opacity); err == nil {
+		if e != nil {
+			if p, err := r.computePattern(ctx, e, opacity, bounds); err == nil {
 				return p, nil
 			}
 		}
@@ -238,60 +495,48 @@ func (r *renderer) computePaint(p *Paint, opacity float64) (gg.Pattern, error) {
 
 	c := p.Color
 	if c != nil {
-		sr, sg, sb, sa := c.RGBA()
-		if sa == 0 {
-			opacity = 0
-		}
-		c = color.NRGBA{
-			R: byte(sr),
-			G: byte(sg),
-			B: byte(sb),
-			A: byte(opacity * 255),
-		}
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+		nc.A = uint8(opacity*float64(nc.A) + 0.5)
+		c = nc
 	} else {
 		c = color.Transparent
 	}
 	return gg.NewSolidPattern(c), nil
 }
 
-func (r *renderer) setPaints(ctx *gg.Context) error {
-	// Compute the fill (TODO: style)
-	fillOpacity := r.computeNumberPercentage(1.0, r.getFillOpacity())
-	fill, err := r.computePaint(r.getFill(), fillOpacity)
-	if err != nil {
-		return err
+// setFill sets the fill style and fill rule for the element at the top of the stack. bounds is
+// the bounding box of the element's geometry. Strokes are rendered separately by stroke.
+func (r *renderer) setFill(ctx *gg.Context, bounds boundingBox) error {
+	// The contents of a clipPath are rendered as solid silhouettes.
+	if r.clipping {
+		ctx.SetFillStyle(gg.NewSolidPattern(color.White))
+		if r.getClipRule() == "evenodd" {
+			ctx.SetFillRuleEvenOdd()
+		} else {
+			ctx.SetFillRuleWinding()
+		}
+		return nil
 	}
 
-	// Compute the stroke (TODO: style)
-	strokeOpacity := r.computeNumberPercentage(1.0, r.getStrokeOpacity())
-	stroke, err := r.computePaint(r.getStroke(), strokeOpacity)
+	fillOpacity := r.computeNumberPercentage(1.0, r.getFillOpacity())
+	fill, err := r.computePaint(ctx, r.getFill(), fillOpacity, bounds)
 	if err != nil {
 		return err
 	}
+	ctx.SetFillStyle(fill)
 
-	// Handle line caps
-	switch r.getStrokeLinecap() {
-	case "butt":
-		ctx.SetLineCap(gg.LineCapButt)
-	case "round":
-		ctx.SetLineCap(gg.LineCapRound)
-	case "square":
-		ctx.SetLineCap(gg.LineCapSquare)
-	}
-
-	// Handle line width
-	strokeWidth := 1.0
-	if sw := r.getStrokeWidth(); sw != nil {
-		strokeWidth = r.computeLengthPercentage(r.diag(), *sw)
+	if r.getFillRule() == "evenodd" {
+		ctx.SetFillRuleEvenOdd()
+	} else {
+		ctx.SetFillRuleWinding()
 	}
-	ctx.SetLineWidth(strokeWidth)
-
-	ctx.SetFillStyle(fill)
-	ctx.SetStrokeStyle(stroke)
 	return nil
 }
 
-func (r *renderer) renderCompositingGroup(ctx *gg.Context, isolated bool, elements []any) error {
+// renderCompositingGroup renders a list of elements as a non-isolated compositing group, i.e.
+// directly onto the group backdrop. Groups that must be isolated, whether due to group
+// opacity, masking, blending, or the isolation property, are isolated by renderElement.
+func (r *renderer) renderCompositingGroup(ctx *gg.Context, elements []any) error {
 	for _, e := range elements {
 		if err := r.renderElement(ctx, e.X); err != nil {
 			return err
@@ -301,6 +546,57 @@ func (r *renderer) renderCompositingGroup(ctx *gg.Context, isolated bool, elemen
 }
 
 func (r *renderer) renderElement(ctx *gg.Context, e Element) error {
+	switch e.(type) {
+	case *Defs, *Marker, *ClipPath, *Mask, *Filter, *Symbol, *LinearGradient, *RadialGradient, *Pattern, *Style:
+		// Never rendered
+		return nil
+	}
+
+	if clip := e.attrs().ClipPath; clip != nil {
+		mask, err := r.clipMask(ctx, e, clip)
+		if err != nil {
+			return err
+		}
+		if mask != nil {
+			r.pushClip(ctx, mask)
+			defer r.popClip(ctx)
+		}
+	}
+
+	var mask *image.Alpha
+	if m := e.attrs().Mask; m != nil && m.URL != "" {
+		var err error
+		if mask, err = r.computeMask(ctx, e, m.URL); err != nil {
+			return err
+		}
+	}
+
+	render := func(ctx *gg.Context) error {
+		if filters := e.attrs().Filter; filters != nil && len(filters.URLs) != 0 {
+			return r.renderFiltered(ctx, e, filters)
+		}
+		return r.renderGraphics(ctx, e)
+	}
+
+	// Elements with group opacity, a mask, or a blend mode are rendered as isolated groups so
+	// that these effects apply to the element as a whole rather than to each of its parts.
+	opacity := math.Max(0, math.Min(1, r.computeNumberPercentage(1, e.attrs().Opacity)))
+	mode := e.attrs().MixBlendMode
+	if mode == "" {
+		mode = "normal"
+	}
+	if opacity < 1 || mask != nil || mode != "normal" || e.attrs().Isolation == "isolate" {
+		if opacity == 0 {
+			return nil
+		}
+		return r.renderIsolated(ctx, opacity, mask, string(mode), render)
+	}
+	return render(ctx)
+}
+
+// renderGraphics renders an element's graphics without regard to its clip-path, mask, or
+// filter properties.
+func (r *renderer) renderGraphics(ctx *gg.Context, e Element) error {
 	switch e := e.(type) {
 	case *Grouping:
 		return r.renderGrouping(ctx, e)
@@ -324,21 +620,26 @@ func (r *renderer) renderElement(ctx *gg.Context, e Element) error {
 		return r.renderPolygon(ctx, e)
 	case *Text:
 		return r.renderText(ctx, e)
-	case *TSpan:
-		return r.renderTSpan(ctx, e)
+	case *TSpan, *TextPath:
+		// Text content child elements are only rendered as part of a text element.
+		return nil
 	case *Image:
 		return r.renderImage(ctx, e)
 	case *ForeignObject:
 		return r.renderForeignObject(ctx, e)
-	case *Defs, *Marker, *Symbol, *LinearGradient, *RadialGradient, *Pattern:
-		// Never rendered
-		return nil
 	default:
 		panic(fmt.Errorf("unexpected element type %T", e))
 	}
 }
 
 func (r *renderer) renderGrouping(ctx *gg.Context, e *Grouping) error {
+	ctx.Push()
+	defer ctx.Pop()
+
+	if !r.transform(ctx, e) {
+		return nil
+	}
+
 	r.push(e, r.width(), r.height())
 	defer r.pop()
 
@@ -351,8 +652,128 @@ func (r *renderer) renderGrouping(ctx *gg.Context, e *Grouping) error {
 	return nil
 }
 
+// lookup returns the element referenced by the given URL. If no such element exists, lookup
+// returns nil.
+func (r *renderer) lookup(url string) (Element, error) {
+	if url == "" || url[0] != '#' {
+		return nil, errors.New("NYI: non-fragment URLs")
+	}
+	return r.elements[url[1:]], nil
+}
+
+// isCircular returns true if the given use element references itself, either directly or
+// indirectly.
+func (r *renderer) isCircular(use *Use) bool {
+	visited := map[Element]bool{}
+
+	var references func(e Element) bool
+	references = func(e Element) bool {
+		if visited[e] {
+			return false
+		}
+		visited[e] = true
+
+		found := false
+		walkElement(e, func(e Element) {
+			if u, ok := e.(*Use); ok && !found {
+				if u == use {
+					found = true
+				} else if target, _ := r.lookup(u.Href); target != nil {
+					found = references(target)
+				}
+			}
+		})
+		return found
+	}
+
+	target, _ := r.lookup(use.Href)
+	return target != nil && references(target)
+}
+
 func (r *renderer) renderUse(ctx *gg.Context, e *Use) error {
-	return errors.New("NYI: use")
+	// References to missing elements are not rendered.
+	target, err := r.lookup(e.Href)
+	if err != nil || target == nil {
+		return err
+	}
+
+	// A use element that references itself, directly or indirectly, is in error and is not
+	// rendered.
+	if r.isCircular(e) {
+		return nil
+	}
+
+	ctx.Push()
+	defer ctx.Pop()
+
+	if !r.transform(ctx, e) {
+		return nil
+	}
+
+	// The x and y properties define an additional transformation.
+	x, y := r.computeLengthPercentage(r.width(), e.X), r.computeLengthPercentage(r.height(), e.Y)
+	ctx.Translate(x, y)
+
+	// The referenced element is rendered as if it were a child of the use element, so it
+	// inherits properties from the use element rather than from its own ancestors.
+	r.push(e, r.width(), r.height())
+	defer r.pop()
+
+	if s, ok := target.(*Symbol); ok {
+		return r.renderSymbol(ctx, s, e)
+	}
+	return r.renderElement(ctx, target)
+}
+
+// renderSymbol renders a symbol element that is referenced by the given use element. The
+// symbol establishes a new viewport whose size is determined by the use element's width and
+// height, if present, or by the symbol's own width and height.
+func (r *renderer) renderSymbol(ctx *gg.Context, e *Symbol, use *Use) error {
+	ctx.Push()
+	defer ctx.Pop()
+
+	width, height := e.Width, e.Height
+	if use.Width != nil {
+		width = use.Width
+	}
+	if use.Height != nil {
+		height = use.Height
+	}
+
+	x, y := r.computeLengthPercentage(r.width(), e.X), r.computeLengthPercentage(r.height(), e.Y)
+	w, h := r.computeBoxLengthPercentage(r.width(), r.width(), width), r.computeBoxLengthPercentage(r.height(), r.height(), height)
+
+	// A zero-sized viewport disables rendering of the element.
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	// Symbols clip their contents to their viewport unless their overflow is visible.
+	switch e.Overflow {
+	case "visible", "auto":
+	default:
+		r.pushClip(ctx, pathMask(ctx, func(dc *gg.Context) {
+			dc.DrawRectangle(x, y, w, h)
+		}))
+		defer r.popClip(ctx)
+	}
+
+	if vb := e.ViewBox; vb != nil {
+		if vb.Width == 0 || vb.Height == 0 {
+			// A zero-sized view box disables rendering of the element.
+			return nil
+		}
+
+		applyViewBox(ctx, vb, e.PreserveAspectRatio, x, y, w, h)
+		w, h = vb.Width, vb.Height
+	} else {
+		ctx.Translate(x, y)
+	}
+
+	r.push(e, w, h)
+	defer r.pop()
+
+	return r.renderCompositingGroup(ctx, e.Children)
 }
 
 func (r *renderer) renderSwitch(ctx *gg.Context, e *Switch) error {
@@ -363,24 +784,57 @@ func (r *renderer) renderPath(ctx *gg.Context, e *Path) error {
 	ctx.Push()
 	defer ctx.Pop()
 
+	if !r.transform(ctx, e) {
+		return nil
+	}
+
 	r.push(e, r.width(), r.height())
 	defer r.pop()
 
-	r.setPaints(ctx)
-
-	// TODO: path length
+	trace := func(path pathBuilder) {
+		tracePath(path, e.D.Commands)
+	}
+	if err := r.paintPath(ctx, e.PathLength, true, trace); err != nil {
+		return err
+	}
+	return r.renderMarkers(ctx, trace)
+}
 
-	p, _ := ctx.GetCurrentPoint()
-	x, y := p.X, p.Y
+// paintPath fills and strokes the path traced by trace using the properties of the element at
+// the top of the stack. pathLength is the value of the element's pathLength attribute. If fill
+// is false, the path is only stroked.
+func (r *renderer) paintPath(ctx *gg.Context, pathLength float64, fill bool, trace func(path pathBuilder)) error {
+	stroke := &strokePath{path: ctx, scale: strokeScale(currentMatrix(ctx))}
+	path := &boundsPath{path: stroke}
+	ctx.ClearPath()
+	trace(path)
 
-	active, subpath := false, false
+	if fill {
+		if err := r.setFill(ctx, path.bounds); err != nil {
+			return err
+		}
+		ctx.FillPreserve()
+	}
+	err := r.stroke(ctx, stroke, path.bounds, pathLength, path.length)
 	ctx.ClearPath()
-	for i, c := range e.D.Commands {
+	return err
+}
+
+// tracePath adds the segments described by the given path commands to a path.
+func tracePath(path pathBuilder, commands []PathCommand) {
+	x, y := 0.0, 0.0
+
+	// (cx, cy) is the final control point of the previous segment. lastCurve records whether
+	// that segment was a cubic ('C') or quadratic ('Q') Bézier curve, if it was either.
+	cx, cy, lastCurve := 0.0, 0.0, byte(0)
+
+	active := false
+	for _, c := range commands {
+		curve := byte(0)
 		switch c := c.(type) {
 		case *MoveTo:
 			if active {
-				ctx.NewSubPath()
-				subpath = true
+				path.NewSubPath()
 			}
 			active = true
 
@@ -389,7 +843,7 @@ func (r *renderer) renderPath(ctx *gg.Context, e *Path) error {
 			} else {
 				x, y = c.Points[0].X, c.Points[0].Y
 			}
-			ctx.MoveTo(x, y)
+			path.MoveTo(x, y)
 
 			for _, p := range c.Points[1:] {
 				if !c.IsAbsolute {
@@ -397,13 +851,10 @@ func (r *renderer) renderPath(ctx *gg.Context, e *Path) error {
 				} else {
 					x, y = p.X, p.Y
 				}
-				ctx.LineTo(x, y)
+				path.LineTo(x, y)
 			}
 		case *ClosePath:
-			ctx.ClosePath()
-			if subpath {
-				ctx.ClipPreserve()
-			}
+			path.ClosePath()
 			active = false
 		case *LineTo:
 			for _, p := range c.Points {
@@ -422,63 +873,183 @@ func (r *renderer) renderPath(ctx *gg.Context, e *Path) error {
 						y = p.Y
 					}
 				}
-				ctx.LineTo(x, y)
+				path.LineTo(x, y)
 			}
 		case *CubicBezier:
-
 			// If the current point is (curx, cury) and the final control point of the
 			// previous path segment is (oldx2, oldy2), then the reflected point (i.e.,
 			// (newx1, newy1), the first control point of the current path segment) is:
 			//
 			// (newx1, newy1) = (curx - (oldx2 - curx), cury - (oldy2 - cury))
 			//                = (2*curx - oldx2, 2*cury - oldy2)
+			//
+			// If the previous segment was not a cubic Bézier curve, the first control point
+			// is coincident with the current point.
 
-			x1, y1, x2, y2 := 0.0, 0.0, 0.0, 0.0
 			for _, p := range c.Coordinates {
-				if c.IsSmooth {
-					hasPreviousPoint := false
-					if i > 0 {
-						_, hasPreviousPoint = e.D.Commands[i-1].(*CubicBezier)
-					}
-					if !hasPreviousPoint {
-						x1, y1 = x, y
-					} else {
-						x1, y1 = 2*x-x2, 2*y-y2
-					}
+				var x1, y1 float64
+				switch {
+				case !c.IsSmooth && !c.IsAbsolute:
+					x1, y1 = x+p.X1, y+p.Y1
+				case !c.IsSmooth:
+					x1, y1 = p.X1, p.Y1
+				case lastCurve == 'C':
+					x1, y1 = 2*x-cx, 2*y-cy
+				default:
+					x1, y1 = x, y
 				}
 
 				if !c.IsAbsolute {
-					if !c.IsSmooth {
-						x1, y1 = x+p.X1, y+p.Y1
-					}
-					x2, y2 = x+p.X2, y+p.Y2
+					cx, cy = x+p.X2, y+p.Y2
 					x, y = x+p.X, y+p.Y
 				} else {
-					if !c.IsSmooth {
-						x1, y1 = p.X1, p.Y1
-					}
-					x2, y2 = p.X2, p.Y2
+					cx, cy = p.X2, p.Y2
 					x, y = p.X, p.Y
 				}
-				ctx.CubicTo(x1, y1, x2, y2, x, y)
+				path.CubicTo(x1, y1, cx, cy, x, y)
+				lastCurve = 'C'
 			}
+			curve = 'C'
 		case *QuadraticBezier:
-			return errors.New("NYI: quadratic bezier")
+			// The control point of a smooth quadratic Bézier curve is the reflection of the
+			// control point of the previous segment if that segment was also a quadratic
+			// Bézier curve. Otherwise, the control point is coincident with the current point.
+
+			for _, p := range c.Coordinates {
+				switch {
+				case !c.IsSmooth && !c.IsAbsolute:
+					cx, cy = x+p.X1, y+p.Y1
+				case !c.IsSmooth:
+					cx, cy = p.X1, p.Y1
+				case lastCurve == 'Q':
+					cx, cy = 2*x-cx, 2*y-cy
+				default:
+					cx, cy = x, y
+				}
+
+				if !c.IsAbsolute {
+					x, y = x+p.X, y+p.Y
+				} else {
+					x, y = p.X, p.Y
+				}
+				path.QuadraticTo(cx, cy, x, y)
+				lastCurve = 'Q'
+			}
+			curve = 'Q'
 		case *EllipticalArc:
-			return errors.New("NYI: elliptical arc")
+			for _, p := range c.Coordinates {
+				x0, y0 := x, y
+				if !c.IsAbsolute {
+					x, y = x+p.X, y+p.Y
+				} else {
+					x, y = p.X, p.Y
+				}
+				if a, ok := path.(arcBuilder); ok {
+					a.ArcTo(x0, y0, p.Rx, p.Ry, p.XAxisRotation, p.LargeArc, p.Sweep, x, y)
+				} else {
+					arcTo(path, x0, y0, p.Rx, p.Ry, p.XAxisRotation, p.LargeArc, p.Sweep, x, y)
+				}
+			}
 		}
+		lastCurve = curve
 	}
-	ctx.FillPreserve()
-	ctx.StrokePreserve()
-	ctx.ClearPath()
+}
 
-	return nil
+// arcTo adds an elliptical arc from (x1, y1) to (x2, y2) to the current path. The arc is
+// approximated using cubic Bézier curves.
+//
+// The arc is converted from the endpoint parameterization used by SVG path data to a center
+// parameterization as described in section B.2.4 of the SVG 2 specification. Out-of-range
+// radii are corrected as described in section B.2.5.
+func arcTo(path pathBuilder, x1, y1, rx, ry, xAxisRotation float64, largeArc, sweep bool, x2, y2 float64) {
+	// If the endpoints are identical, the arc is omitted.
+	if x1 == x2 && y1 == y2 {
+		return
+	}
+
+	// If either radius is zero, the arc is treated as a straight line.
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		path.LineTo(x2, y2)
+		return
+	}
+
+	phi := gg.Radians(math.Mod(xAxisRotation, 360))
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+
+	// Step 1: compute (x1', y1').
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p, y1p := cosPhi*dx+sinPhi*dy, -sinPhi*dx+cosPhi*dy
+
+	// Ensure that the radii are large enough.
+	if lambda := (x1p*x1p)/(rx*rx) + (y1p*y1p)/(ry*ry); lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx, ry = rx*s, ry*s
+	}
+
+	// Step 2: compute (cx', cy').
+	rx2, ry2 := rx*rx, ry*ry
+	num := rx2*ry2 - rx2*y1p*y1p - ry2*x1p*x1p
+	den := rx2*y1p*y1p + ry2*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+
+	// Step 3: compute (cx, cy) from (cx', cy').
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x2)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y2)/2
+
+	// Step 4: compute the start angle and the sweep angle.
+	theta1 := math.Atan2((y1p-cyp)/ry, (x1p-cxp)/rx)
+	dtheta := math.Atan2((-y1p-cyp)/ry, (-x1p-cxp)/rx) - theta1
+	switch {
+	case sweep && dtheta < 0:
+		dtheta += 2 * math.Pi
+	case !sweep && dtheta > 0:
+		dtheta -= 2 * math.Pi
+	}
+
+	// Approximate the arc using one cubic curve per quarter turn or less.
+	point := func(theta float64) (float64, float64) {
+		x, y := rx*math.Cos(theta), ry*math.Sin(theta)
+		return cx + cosPhi*x - sinPhi*y, cy + sinPhi*x + cosPhi*y
+	}
+	derivative := func(theta float64) (float64, float64) {
+		x, y := -rx*math.Sin(theta), ry*math.Cos(theta)
+		return cosPhi*x - sinPhi*y, sinPhi*x + cosPhi*y
+	}
+
+	n := int(math.Ceil(math.Abs(dtheta)/(math.Pi/2) - 1e-9))
+	delta := dtheta / float64(n)
+	k := 4.0 / 3.0 * math.Tan(delta/4)
+
+	t0 := theta1
+	px, py := x1, y1
+	for i := 0; i < n; i++ {
+		t1 := t0 + delta
+
+		qx, qy := point(t1)
+		if i == n-1 {
+			qx, qy = x2, y2
+		}
+		d0x, d0y := derivative(t0)
+		d1x, d1y := derivative(t1)
+		path.CubicTo(px+k*d0x, py+k*d0y, qx-k*d1x, qy-k*d1y, qx, qy)
+
+		t0, px, py = t1, qx, qy
+	}
 }
 
 func (r *renderer) renderRect(ctx *gg.Context, e *Rect) error {
 	ctx.Push()
 	defer ctx.Pop()
 
+	if !r.transform(ctx, e) {
+		return nil
+	}
+
 	x0, y0 := r.computeLengthPercentage(r.width(), e.X), r.computeLengthPercentage(r.height(), e.Y)
 	w, h := r.computeBoxLengthPercentage(r.width(), r.width(), &e.Width), r.computeBoxLengthPercentage(r.height(), r.height(), &e.Height)
 
@@ -489,6 +1060,13 @@ func (r *renderer) renderRect(ctx *gg.Context, e *Rect) error {
 	rx := r.computeBoxLengthPercentage(r.width(), 0, e.Rx)
 	ry := r.computeBoxLengthPercentage(r.height(), rx, e.Ry)
 
+	// Radii are clamped to half of the rectangle's size. If either radius is zero, the
+	// rectangle's corners are square.
+	rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+	if rx <= 0 || ry <= 0 {
+		rx, ry = 0, 0
+	}
+
 	x1, y1 := x0+rx, y0+ry
 	x2, y2 := x0+w-rx, y0+h-ry
 	x3, y3 := x0+w, y0+h
@@ -496,164 +1074,138 @@ func (r *renderer) renderRect(ctx *gg.Context, e *Rect) error {
 	r.push(e, w, h)
 	defer r.pop()
 
-	r.setPaints(ctx)
-
-	ctx.ClearPath()
-	ctx.MoveTo(x1, y0)
-	ctx.LineTo(x2, y0)
-	ctx.DrawEllipticalArc(x2, y1, rx, ry, gg.Radians(270), gg.Radians(360))
-	ctx.LineTo(x3, y2)
-	ctx.DrawEllipticalArc(x2, y2, rx, ry, gg.Radians(0), gg.Radians(90))
-	ctx.LineTo(x1, y3)
-	ctx.DrawEllipticalArc(x1, y2, rx, ry, gg.Radians(90), gg.Radians(180))
-	ctx.LineTo(x0, y1)
-	ctx.DrawEllipticalArc(x1, y1, rx, ry, gg.Radians(180), gg.Radians(270))
-	ctx.FillPreserve()
-	ctx.StrokePreserve()
-	ctx.ClosePath()
-
-	return nil
+	return r.paintPath(ctx, e.PathLength, true, func(path pathBuilder) {
+		path.MoveTo(x1, y0)
+		path.LineTo(x2, y0)
+		if rx > 0 {
+			ellipticalArc(path, x2, y1, rx, ry, gg.Radians(270), gg.Radians(360))
+		}
+		path.LineTo(x3, y2)
+		if rx > 0 {
+			ellipticalArc(path, x2, y2, rx, ry, gg.Radians(0), gg.Radians(90))
+		}
+		path.LineTo(x1, y3)
+		if rx > 0 {
+			ellipticalArc(path, x1, y2, rx, ry, gg.Radians(90), gg.Radians(180))
+		}
+		path.LineTo(x0, y1)
+		if rx > 0 {
+			ellipticalArc(path, x1, y1, rx, ry, gg.Radians(180), gg.Radians(270))
+		}
+		path.ClosePath()
+	})
 }
 
 func (r *renderer) renderCircle(ctx *gg.Context, e *Circle) error {
 	ctx.Push()
 	defer ctx.Pop()
 
+	if !r.transform(ctx, e) {
+		return nil
+	}
+
 	cx, cy := r.computeLengthPercentage(r.width(), e.Cx), r.computeLengthPercentage(r.height(), e.Cy)
 	rr := r.computeLengthPercentage(r.diag(), e.R)
 
 	r.push(e, rr, rr)
 	defer r.pop()
 
-	r.setPaints(ctx)
-
-	ctx.ClearPath()
-	ctx.DrawCircle(cx, cy, rr)
-	ctx.FillPreserve()
-	ctx.StrokePreserve()
-
-	return nil
+	return r.paintPath(ctx, e.PathLength, true, func(path pathBuilder) {
+		ellipse(path, cx, cy, rr, rr)
+	})
 }
 
 func (r *renderer) renderEllipse(ctx *gg.Context, e *Ellipse) error {
-	return errors.New("NYI: ellipse")
-}
+	ctx.Push()
+	defer ctx.Pop()
 
-func (r *renderer) renderLine(ctx *gg.Context, e *Line) error {
-	return errors.New("NYI: line")
-}
+	if !r.transform(ctx, e) {
+		return nil
+	}
 
-func (r *renderer) renderPolyline(ctx *gg.Context, e *Polyline) error {
-	return errors.New("NYI: polyline")
-}
+	cx, cy := r.computeLengthPercentage(r.width(), e.Cx), r.computeLengthPercentage(r.height(), e.Cy)
 
-func (r *renderer) renderPolygon(ctx *gg.Context, e *Polygon) error {
-	return errors.New("NYI: polygon")
+	// An auto radius takes the value of the other radius.
+	rx := r.computeBoxLengthPercentage(r.width(), math.NaN(), e.Rx)
+	ry := r.computeBoxLengthPercentage(r.height(), rx, e.Ry)
+	if math.IsNaN(rx) {
+		rx = ry
+	}
+
+	// A zero or negative radius disables rendering of the element.
+	if !(rx > 0 && ry > 0) {
+		return nil
+	}
+
+	r.push(e, r.width(), r.height())
+	defer r.pop()
+
+	return r.paintPath(ctx, e.PathLength, true, func(path pathBuilder) {
+		ellipse(path, cx, cy, rx, ry)
+	})
 }
 
-func (r *renderer) renderText(ctx *gg.Context, e *Text) error {
+func (r *renderer) renderLine(ctx *gg.Context, e *Line) error {
 	ctx.Push()
 	defer ctx.Pop()
 
-	cssStyle := r.getFontStyle()
-	style := font.StyleNormal
-	if cssStyle == "italic" {
-		style = font.StyleItalic
-	}
-
-	cssWeight := r.getFontWeight()
-	weight := font.WeightNormal
-	switch cssWeight.Ident {
-	case "":
-		switch cssWeight.Number {
-		case 100:
-			weight = font.WeightThin
-		case 200:
-			weight = font.WeightExtraLight
-		case 300:
-			weight = font.WeightLight
-		case 400:
-			weight = font.WeightNormal
-		case 500:
-			weight = font.WeightMedium
-		case 600:
-			weight = font.WeightSemiBold
-		case 700:
-			weight = font.WeightBold
-		case 800:
-			weight = font.WeightExtraBold
-		case 900:
-			weight = font.WeightBlack
-		default:
-			weight = font.Weight(cssWeight.Number/100 - 4)
-		}
-	case "normal":
-		weight = font.WeightNormal
-	case "bold":
-		weight = font.WeightBold
-	case "bolder", "lighter":
-		return errors.New("NYI: bolder/lighter")
-	}
-
-	cssSize := r.getFontSize()
-	size := 0.0
-	if cssSize.Ident != "" {
-		return errors.New("NYI: named font sizes")
-	}
-	switch {
-	case cssSize.Length.Value != 0:
-		if cssSize.Length.Units != "pt" {
-			return errors.New("NYI: non-pt font sizes")
-		}
-		size = cssSize.Length.Value
-	case cssSize.Percentage != 0:
-		return errors.New("NYI: percentage font size")
-	default:
-		size = cssSize.Number
+	if !r.transform(ctx, e) {
+		return nil
 	}
 
-	// TODO: stretch
-
-	ax, ay := 0.0, 0.0
-	switch r.getTextAnchor() {
-	case "middle":
-		ax, ay = 0.5, 0
-	case "end":
-		ax, ay = 1.0, 0
-	}
+	x1, y1 := r.computeLengthPercentageNumber(r.width(), e.X1), r.computeLengthPercentageNumber(r.height(), e.Y1)
+	x2, y2 := r.computeLengthPercentageNumber(r.width(), e.X2), r.computeLengthPercentageNumber(r.height(), e.Y2)
 
 	r.push(e, r.width(), r.height())
 	defer r.pop()
 
-	r.setPaints(ctx)
-	ctx.ClearPath()
-
-	fontFamily, err := r.resolveFontFamily(r.getFontFamily())
-	if err != nil {
-		return err
+	trace := func(path pathBuilder) {
+		path.MoveTo(x1, y1)
+		path.LineTo(x2, y2)
 	}
-	face, err := fontFamily.newFace(weight, style, size, font.HintingNone)
-	if err != nil {
+
+	// Lines are never filled.
+	if err := r.paintPath(ctx, e.PathLength, false, trace); err != nil {
 		return err
 	}
-	ctx.SetFontFace(face)
+	return r.renderMarkers(ctx, trace)
+}
 
-	if len(e.X.Values) != 1 || len(e.Y.Values) != 1 {
-		return errors.New("NYI: x/y lists; dx/dy")
+// renderPoly renders a polyline or polygon with the given points and path length. If closed is
+// true, the last point is connected to the first.
+func (r *renderer) renderPoly(ctx *gg.Context, e Element, points PolyPoints, pathLength float64, closed bool) error {
+	ctx.Push()
+	defer ctx.Pop()
+
+	// A single point is equivalent to a lone moveto, which is neither filled nor stroked.
+	if !r.transform(ctx, e) || len(points) < 2 {
+		return nil
 	}
 
-	x, y := r.computeLengthPercentageNumber(r.width(), e.X.Values[0]), r.computeLengthPercentageNumber(r.height(), e.Y.Values[0])
+	r.push(e, r.width(), r.height())
+	defer r.pop()
 
-	if len(e.Rotate.Values) != 0 {
-		return errors.New("NYI: rotate")
+	trace := func(path pathBuilder) {
+		path.MoveTo(points[0].X, points[0].Y)
+		for _, p := range points[1:] {
+			path.LineTo(p.X, p.Y)
+		}
+		if closed {
+			path.ClosePath()
+		}
+	}
+	if err := r.paintPath(ctx, pathLength, true, trace); err != nil {
+		return err
 	}
+	return r.renderMarkers(ctx, trace)
+}
 
-	ctx.DrawStringAnchored(e.Value, x, y, ax, ay)
-	return nil
+func (r *renderer) renderPolyline(ctx *gg.Context, e *Polyline) error {
+	return r.renderPoly(ctx, e, e.Points, e.PathLength, false)
 }
 
-func (r *renderer) renderTSpan(ctx *gg.Context, e *TSpan) error {
-	return errors.New("NYI: tspan")
+func (r *renderer) renderPolygon(ctx *gg.Context, e *Polygon) error {
+	return r.renderPoly(ctx, e, e.Points, e.PathLength, true)
 }
 
 func (r *renderer) renderImage(ctx *gg.Context, e *Image) error {
@@ -0,0 +1,286 @@
+package svg
+
+import (
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// boundingBox is an axis-aligned bounding box in user space. The zero value is an empty box.
+type boundingBox struct {
+	minX, minY, maxX, maxY float64
+	valid                  bool
+}
+
+// rectBoundingBox returns the bounding box of the given rectangle.
+func rectBoundingBox(x, y, width, height float64) boundingBox {
+	var b boundingBox
+	b.addPoint(x, y)
+	b.addPoint(x+width, y+height)
+	return b
+}
+
+func (b *boundingBox) width() float64 {
+	return b.maxX - b.minX
+}
+
+func (b *boundingBox) height() float64 {
+	return b.maxY - b.minY
+}
+
+// matrix returns the transform that maps the unit square onto the bounding box.
+func (b *boundingBox) matrix() gg.Matrix {
+	return gg.Scale(b.width(), b.height()).Multiply(gg.Translate(b.minX, b.minY))
+}
+
+// addPoint extends the bounding box to include the given point.
+func (b *boundingBox) addPoint(x, y float64) {
+	if !b.valid {
+		b.minX, b.minY, b.maxX, b.maxY, b.valid = x, y, x, y, true
+		return
+	}
+	b.minX, b.minY = math.Min(b.minX, x), math.Min(b.minY, y)
+	b.maxX, b.maxY = math.Max(b.maxX, x), math.Max(b.maxY, y)
+}
+
+// addQuadratic extends the bounding box to include the quadratic Bézier curve with the given
+// control points.
+func (b *boundingBox) addQuadratic(x0, y0, x1, y1, x2, y2 float64) {
+	b.addPoint(x2, y2)
+
+	// The extrema of the curve occur where its derivative is zero.
+	at := func(p0, p1, p2, t float64) float64 {
+		mt := 1 - t
+		return mt*mt*p0 + 2*mt*t*p1 + t*t*p2
+	}
+	for _, t := range []float64{(x0 - x1) / (x0 - 2*x1 + x2), (y0 - y1) / (y0 - 2*y1 + y2)} {
+		if t > 0 && t < 1 {
+			b.addPoint(at(x0, x1, x2, t), at(y0, y1, y2, t))
+		}
+	}
+}
+
+// addCubic extends the bounding box to include the cubic Bézier curve with the given control
+// points.
+func (b *boundingBox) addCubic(x0, y0, x1, y1, x2, y2, x3, y3 float64) {
+	b.addPoint(x3, y3)
+
+	at := func(p0, p1, p2, p3, t float64) float64 {
+		mt := 1 - t
+		return mt*mt*mt*p0 + 3*mt*mt*t*p1 + 3*mt*t*t*p2 + t*t*t*p3
+	}
+
+	// The extrema of the curve occur where its derivative, a quadratic in t, is zero.
+	roots := func(p0, p1, p2, p3 float64) []float64 {
+		a, bb, c := p3-3*p2+3*p1-p0, 2*(p2-2*p1+p0), p1-p0
+		if math.Abs(a) < 1e-12 {
+			if bb == 0 {
+				return nil
+			}
+			return []float64{-c / bb}
+		}
+		disc := bb*bb - 4*a*c
+		if disc < 0 {
+			return nil
+		}
+		sqrt := math.Sqrt(disc)
+		return []float64{(-bb + sqrt) / (2 * a), (-bb - sqrt) / (2 * a)}
+	}
+
+	for _, t := range roots(x0, x1, x2, x3) {
+		if t > 0 && t < 1 {
+			b.addPoint(at(x0, x1, x2, x3, t), at(y0, y1, y2, y3, t))
+		}
+	}
+	for _, t := range roots(y0, y1, y2, y3) {
+		if t > 0 && t < 1 {
+			b.addPoint(at(x0, x1, x2, x3, t), at(y0, y1, y2, y3, t))
+		}
+	}
+}
+
+// pathBuilder is the subset of gg.Context's path construction methods used to build SVG
+// paths.
+type pathBuilder interface {
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	QuadraticTo(x1, y1, x2, y2 float64)
+	CubicTo(x1, y1, x2, y2, x3, y3 float64)
+	ClosePath()
+	NewSubPath()
+}
+
+// boundsPath is a pathBuilder that tracks the bounding box and length of a path. If path is
+// not nil, segments are also added to it.
+type boundsPath struct {
+	path   pathBuilder
+	bounds boundingBox
+	length float64
+
+	// (x, y) is the current point; (sx, sy) is the start of the current subpath.
+	x, y, sx, sy float64
+}
+
+func (p *boundsPath) MoveTo(x, y float64) {
+	if p.path != nil {
+		p.path.MoveTo(x, y)
+	}
+	p.bounds.addPoint(x, y)
+	p.x, p.y, p.sx, p.sy = x, y, x, y
+}
+
+func (p *boundsPath) LineTo(x, y float64) {
+	if p.path != nil {
+		p.path.LineTo(x, y)
+	}
+	p.bounds.addPoint(x, y)
+	p.length += math.Hypot(x-p.x, y-p.y)
+	p.x, p.y = x, y
+}
+
+func (p *boundsPath) QuadraticTo(x1, y1, x2, y2 float64) {
+	if p.path != nil {
+		p.path.QuadraticTo(x1, y1, x2, y2)
+	}
+	p.bounds.addQuadratic(p.x, p.y, x1, y1, x2, y2)
+	p.length += curveLength(func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*p.x + 2*mt*t*x1 + t*t*x2, mt*mt*p.y + 2*mt*t*y1 + t*t*y2
+	})
+	p.x, p.y = x2, y2
+}
+
+func (p *boundsPath) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	if p.path != nil {
+		p.path.CubicTo(x1, y1, x2, y2, x3, y3)
+	}
+	p.bounds.addCubic(p.x, p.y, x1, y1, x2, y2, x3, y3)
+	p.length += curveLength(func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*mt*p.x + 3*mt*mt*t*x1 + 3*mt*t*t*x2 + t*t*t*x3, mt*mt*mt*p.y + 3*mt*mt*t*y1 + 3*mt*t*t*y2 + t*t*t*y3
+	})
+	p.x, p.y = x3, y3
+}
+
+func (p *boundsPath) ClosePath() {
+	if p.path != nil {
+		p.path.ClosePath()
+	}
+	p.length += math.Hypot(p.sx-p.x, p.sy-p.y)
+	p.x, p.y = p.sx, p.sy
+}
+
+func (p *boundsPath) NewSubPath() {
+	if p.path != nil {
+		p.path.NewSubPath()
+	}
+}
+
+// curveLength approximates the length of a parametric curve defined over [0, 1] by
+// flattening it into line segments.
+func curveLength(at func(t float64) (float64, float64)) float64 {
+	const segments = 32
+
+	length := 0.0
+	x0, y0 := at(0)
+	for i := 1; i <= segments; i++ {
+		x1, y1 := at(float64(i) / segments)
+		length += math.Hypot(x1-x0, y1-y0)
+		x0, y0 = x1, y1
+	}
+	return length
+}
+
+// union extends the bounding box to include another bounding box.
+func (b *boundingBox) union(other boundingBox) {
+	if other.valid {
+		b.addPoint(other.minX, other.minY)
+		b.addPoint(other.maxX, other.maxY)
+	}
+}
+
+// transform returns the bounding box of this bounding box transformed by the given matrix.
+func (b boundingBox) transform(m gg.Matrix) boundingBox {
+	if !b.valid {
+		return b
+	}
+
+	var result boundingBox
+	for _, p := range [][2]float64{{b.minX, b.minY}, {b.maxX, b.minY}, {b.maxX, b.maxY}, {b.minX, b.maxY}} {
+		result.addPoint(m.TransformPoint(p[0], p[1]))
+	}
+	return result
+}
+
+// elementBounds returns the bounding box of the given element's geometry in the element's
+// user space, i.e. not including the element's own transform. The bounding box of a
+// container is the union of the bounding boxes of its children.
+func (r *renderer) elementBounds(e Element) boundingBox {
+	var bounds boundingBox
+	switch e := e.(type) {
+	case *Grouping:
+		bounds = r.childBounds(e.Children)
+	case *Switch:
+		bounds = r.childBounds(e.Children)
+	case *Use:
+		target, _ := r.lookup(e.Href)
+		if target == nil || r.isCircular(e) {
+			break
+		}
+		if s, ok := target.(*Symbol); ok {
+			bounds = r.childBounds(s.Children)
+		} else {
+			bounds = r.elementBounds(target).transform(target.attrs().Transform.Matrix())
+		}
+		x, y := r.computeLengthPercentage(r.width(), e.X), r.computeLengthPercentage(r.height(), e.Y)
+		bounds = bounds.transform(gg.Translate(x, y))
+	case *Path:
+		path := &boundsPath{}
+		tracePath(path, e.D.Commands)
+		bounds = path.bounds
+	case *Rect:
+		x, y := r.computeLengthPercentage(r.width(), e.X), r.computeLengthPercentage(r.height(), e.Y)
+		w, h := r.computeBoxLengthPercentage(r.width(), r.width(), &e.Width), r.computeBoxLengthPercentage(r.height(), r.height(), &e.Height)
+		bounds = rectBoundingBox(x, y, w, h)
+	case *Circle:
+		cx, cy := r.computeLengthPercentage(r.width(), e.Cx), r.computeLengthPercentage(r.height(), e.Cy)
+		rr := r.computeLengthPercentage(r.diag(), e.R)
+		bounds = rectBoundingBox(cx-rr, cy-rr, 2*rr, 2*rr)
+	case *Ellipse:
+		cx, cy := r.computeLengthPercentage(r.width(), e.Cx), r.computeLengthPercentage(r.height(), e.Cy)
+		rx := r.computeBoxLengthPercentage(r.width(), math.NaN(), e.Rx)
+		ry := r.computeBoxLengthPercentage(r.height(), rx, e.Ry)
+		if math.IsNaN(rx) {
+			rx = ry
+		}
+		bounds = rectBoundingBox(cx-rx, cy-ry, 2*rx, 2*ry)
+	case *Line:
+		bounds.addPoint(r.computeLengthPercentageNumber(r.width(), e.X1), r.computeLengthPercentageNumber(r.height(), e.Y1))
+		bounds.addPoint(r.computeLengthPercentageNumber(r.width(), e.X2), r.computeLengthPercentageNumber(r.height(), e.Y2))
+	case *Polyline:
+		for _, p := range e.Points {
+			bounds.addPoint(p.X, p.Y)
+		}
+	case *Polygon:
+		for _, p := range e.Points {
+			bounds.addPoint(p.X, p.Y)
+		}
+	case *Text:
+		r.push(e, r.width(), r.height())
+		if glyphs, err := r.layoutText(e); err == nil {
+			bounds = textBounds(glyphs)
+		}
+		r.pop()
+	}
+	return bounds
+}
+
+// childBounds returns the union of the bounding boxes of the given elements in their parent's
+// user space.
+func (r *renderer) childBounds(children []any) boundingBox {
+	var bounds boundingBox
+	for _, c := range children {
+		bounds.union(r.elementBounds(c.X).transform(c.X.attrs().Transform.Matrix()))
+	}
+	return bounds
+}
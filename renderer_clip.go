@@ -0,0 +1,270 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// Note that gg does not restore a context's clip mask when its state is popped, so the
+// renderer maintains its own stack of clip masks.
+
+// pathMask returns a mask that covers the interior of the path drawn by the given function.
+// The path is drawn using the current transformation of the given context.
+func pathMask(ctx *gg.Context, path func(dc *gg.Context)) *image.Alpha {
+	dc := gg.NewContext(ctx.Width(), ctx.Height())
+	applyMatrix(dc, currentMatrix(ctx))
+	path(dc)
+	dc.SetColor(color.White)
+	dc.Fill()
+	return dc.AsMask()
+}
+
+// intersectMasks returns the intersection of the given masks.
+func intersectMasks(a, b *image.Alpha) *image.Alpha {
+	result := image.NewAlpha(a.Bounds())
+	for i := range result.Pix {
+		result.Pix[i] = uint8(uint16(a.Pix[i]) * uint16(b.Pix[i]) / 255)
+	}
+	return result
+}
+
+// pushClip intersects the current clip region with the given mask.
+func (r *renderer) pushClip(ctx *gg.Context, mask *image.Alpha) {
+	if n := len(r.clips); n != 0 {
+		mask = intersectMasks(r.clips[n-1], mask)
+	}
+	r.clips = append(r.clips, mask)
+	ctx.SetMask(mask)
+}
+
+// popClip restores the clip region that was active before the last call to pushClip.
+func (r *renderer) popClip(ctx *gg.Context) {
+	r.clips = r.clips[:len(r.clips)-1]
+	if n := len(r.clips); n != 0 {
+		ctx.SetMask(r.clips[n-1])
+	} else {
+		ctx.ResetClip()
+	}
+}
+
+// clipMask returns the mask described by an element's clip-path property, or nil if the
+// property does not describe a clip region. The context's transform must be that of the
+// element's parent.
+func (r *renderer) clipMask(ctx *gg.Context, e Element, clip *ClipSource) (*image.Alpha, error) {
+	ctx.Push()
+	defer ctx.Pop()
+
+	if !r.transform(ctx, e) {
+		return nil, nil
+	}
+
+	switch {
+	case clip.URL != "":
+		target, err := r.lookup(clip.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		// References to missing elements or elements that are not clipPaths are ignored.
+		cp, ok := target.(*ClipPath)
+		if !ok {
+			return nil, nil
+		}
+		return r.clipPathMask(ctx, e, cp)
+	case clip.Shape != nil || clip.Box != "":
+		box := r.referenceBox(e, clip.Box)
+		if !box.valid {
+			return nil, nil
+		}
+		return pathMask(ctx, func(dc *gg.Context) {
+			r.traceBasicShape(dc, clip.Shape, box)
+		}), nil
+	default:
+		return nil, nil
+	}
+}
+
+// clipPathMask renders the contents of a clipPath element into a mask. e is the element
+// being clipped, and the context's transform must be that of e's user space.
+func (r *renderer) clipPathMask(ctx *gg.Context, e Element, cp *ClipPath) (*image.Alpha, error) {
+	dc := gg.NewContext(ctx.Width(), ctx.Height())
+
+	// A clipPath that references itself, directly or indirectly, is in error, and the
+	// element that references it is not rendered.
+	if r.clipPaths[cp] {
+		return dc.AsMask(), nil
+	}
+
+	applyMatrix(dc, currentMatrix(ctx))
+	if !r.transform(dc, cp) {
+		return dc.AsMask(), nil
+	}
+	if cp.ClipPathUnits == ObjectBoundingBox {
+		// An element without a bounding box is not rendered.
+		bounds := r.elementBounds(e)
+		if !bounds.valid || bounds.width() == 0 || bounds.height() == 0 {
+			return dc.AsMask(), nil
+		}
+		dc.Translate(bounds.minX, bounds.minY)
+		dc.Scale(bounds.width(), bounds.height())
+	}
+
+	// The clipPath's contents inherit properties from the clipPath and its ancestors rather
+	// than from the element being clipped. The contents are rendered into their own context, so the
+	// active clip masks are set aside.
+	stack, clips, clipping := r.stack, r.clips, r.clipping
+	r.stack, r.clips, r.clipping = append(r.ancestorStack(cp), &element{Element: cp, width: r.width(), height: r.height()}), nil, true
+	if r.clipPaths == nil {
+		r.clipPaths = map[*ClipPath]bool{}
+	}
+	r.clipPaths[cp] = true
+	defer func() {
+		r.stack, r.clips, r.clipping = stack, clips, clipping
+		delete(r.clipPaths, cp)
+	}()
+
+	// Only shapes, text, and use elements contribute to the clip region.
+	for _, c := range cp.Children {
+		switch c.X.(type) {
+		case *Path, *Rect, *Circle, *Ellipse, *Line, *Polyline, *Polygon, *Text, *Use:
+			if err := r.renderElement(dc, c.X); err != nil {
+				return nil, err
+			}
+		}
+	}
+	mask := dc.AsMask()
+
+	// A clip-path on the clipPath element itself further restricts the clip region.
+	if clip := cp.ClipPath; clip != nil {
+		r.stack, r.clips, r.clipping = stack, clips, clipping
+		inner, err := r.clipMask(ctx, e, clip)
+		if err != nil {
+			return nil, err
+		}
+		if inner != nil {
+			mask = intersectMasks(mask, inner)
+		}
+	}
+	return mask, nil
+}
+
+// referenceBox returns the reference box of a basic shape for the given element. SVG elements
+// have no CSS layout box, so the CSS boxes are mapped onto the fill and stroke boxes.
+func (r *renderer) referenceBox(e Element, box string) boundingBox {
+	switch box {
+	case "view-box":
+		return rectBoundingBox(0, 0, r.width(), r.height())
+	case "fill-box", "content-box", "padding-box":
+		return r.elementBounds(e)
+	default:
+		// The stroke box is approximated by outsetting the fill box by half the stroke width.
+		bounds := r.elementBounds(e)
+		if !bounds.valid {
+			return bounds
+		}
+
+		r.push(e, r.width(), r.height())
+		defer r.pop()
+
+		if stroke := r.getStroke(); stroke != nil && (stroke.URL != "" || stroke.Context != "" || !isTransparent(stroke.Color)) {
+			sw := 1.0
+			if w := r.getStrokeWidth(); w != nil {
+				sw = r.computeLengthPercentage(r.diag(), *w)
+			}
+			bounds = rectBoundingBox(bounds.minX-sw/2, bounds.minY-sw/2, bounds.width()+sw, bounds.height()+sw)
+		}
+		return bounds
+	}
+}
+
+func isTransparent(c color.Color) bool {
+	if c == nil {
+		return true
+	}
+	_, _, _, a := c.RGBA()
+	return a == 0
+}
+
+// traceBasicShape adds the outline of a basic shape to the context's path. Percentages are
+// resolved against the given reference box. If shape is nil, the outline of the reference box
+// itself is added.
+func (r *renderer) traceBasicShape(dc *gg.Context, shape *BasicShape, box boundingBox) {
+	w, h := box.width(), box.height()
+	if shape == nil {
+		dc.DrawRectangle(box.minX, box.minY, w, h)
+		return
+	}
+
+	arg := func(i int, parent float64) float64 {
+		return r.computeLengthPercentage(parent, shape.Args[i].LengthPercentage)
+	}
+
+	// center resolves the center of a circle or ellipse, which defaults to the center of the
+	// reference box.
+	center := func() (float64, float64) {
+		if shape.Position == nil {
+			return box.minX + w/2, box.minY + h/2
+		}
+		return box.minX + r.computeLengthPercentage(w, shape.Position[0]), box.minY + r.computeLengthPercentage(h, shape.Position[1])
+	}
+
+	// radius resolves a shape radius along an axis given the distances from the center to
+	// the edges of the reference box along that axis.
+	radius := func(i int, parent, near, far float64) float64 {
+		if i >= len(shape.Args) {
+			return math.Min(near, far)
+		}
+		switch shape.Args[i].Ident {
+		case "closest-side":
+			return math.Min(near, far)
+		case "farthest-side":
+			return math.Max(near, far)
+		default:
+			return arg(i, parent)
+		}
+	}
+
+	switch shape.Function {
+	case "inset":
+		top, right, bottom, left := arg(0, h), arg(1, w), arg(2, h), arg(3, w)
+		rw, rh := math.Max(0, w-left-right), math.Max(0, h-top-bottom)
+		rr := 0.0
+		if shape.Radius != nil {
+			rr = math.Min(r.computeLengthPercentage(w, *shape.Radius), math.Min(rw, rh)/2)
+		}
+		dc.DrawRoundedRectangle(box.minX+left, box.minY+top, rw, rh, rr)
+	case "circle":
+		cx, cy := center()
+		dx, dy := cx-box.minX, cy-box.minY
+		var rr float64
+		if len(shape.Args) != 0 && shape.Args[0].Ident == "" {
+			rr = arg(0, math.Sqrt((w*w+h*h)/2))
+		} else {
+			near := math.Min(math.Min(dx, w-dx), math.Min(dy, h-dy))
+			far := math.Max(math.Max(dx, w-dx), math.Max(dy, h-dy))
+			if len(shape.Args) != 0 && shape.Args[0].Ident == "farthest-side" {
+				rr = far
+			} else {
+				rr = near
+			}
+		}
+		dc.DrawCircle(cx, cy, rr)
+	case "ellipse":
+		cx, cy := center()
+		dx, dy := cx-box.minX, cy-box.minY
+		rx := radius(0, w, math.Abs(dx), math.Abs(w-dx))
+		ry := radius(1, h, math.Abs(dy), math.Abs(h-dy))
+		dc.DrawEllipse(cx, cy, rx, ry)
+	case "polygon":
+		for i := 0; i+1 < len(shape.Args); i += 2 {
+			dc.LineTo(box.minX+arg(i, w), box.minY+arg(i+1, h))
+		}
+		dc.ClosePath()
+		if shape.FillRule == "evenodd" {
+			dc.SetFillRuleEvenOdd()
+		}
+	}
+}
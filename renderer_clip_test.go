@@ -0,0 +1,122 @@
+package svg
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipPathUnits(t *testing.T) {
+	const clipped = `<rect x="10" y="10" width="20" height="20" fill="red" clip-path="url(#c)"/>`
+
+	img := renderElements(t, 40, 40, `<clipPath id="c"><rect width="15" height="15"/></clipPath>`+clipped)
+	assert.Equal(t, image.Rect(10, 10, 15, 15), paintedBounds(img, red))
+
+	// In objectBoundingBox units, the contents are fractions of the element's bounding box.
+	img = renderElements(t, 40, 40, `<clipPath id="c" clipPathUnits="objectBoundingBox"><rect width="0.5" height="0.5"/></clipPath>`+clipped)
+	assert.Equal(t, image.Rect(10, 10, 20, 20), paintedBounds(img, red))
+
+	img = renderElements(t, 40, 40, `<clipPath id="c" clipPathUnits="objectBoundingBox"><rect x="0.25" y="0.5" width="0.5" height="0.25"/></clipPath>`+clipped)
+	assert.Equal(t, image.Rect(15, 20, 25, 25), paintedBounds(img, red))
+
+	// An element without a bounding box is not rendered.
+	img = renderElements(t, 40, 40, `<clipPath id="c" clipPathUnits="objectBoundingBox"><rect width="1" height="1"/></clipPath>
+		<line x1="0" y1="20" x2="40" y2="20" stroke="red" stroke-width="4" clip-path="url(#c)"/>`)
+	assert.True(t, paintedBounds(img, red).Empty())
+}
+
+func TestClipPathClipPath(t *testing.T) {
+	// A clip-path on the clipPath element intersects its clip region.
+	img := renderElements(t, 40, 40, `
+		<clipPath id="c" clip-path="url(#d)"><rect width="30" height="30"/></clipPath>
+		<clipPath id="d"><rect x="10" y="10" width="30" height="30"/></clipPath>
+		<rect width="40" height="40" fill="red" clip-path="url(#c)"/>`)
+	assert.Equal(t, image.Rect(10, 10, 30, 30), paintedBounds(img, red))
+
+	// So does a clip-path on one of its children, which clips only that child.
+	img = renderElements(t, 40, 40, `
+		<clipPath id="c">
+			<rect width="20" height="20" clip-path="url(#d)"/>
+			<rect x="30" y="30" width="10" height="10"/>
+		</clipPath>
+		<clipPath id="d"><rect x="10" y="10" width="30" height="30"/></clipPath>
+		<rect width="40" height="40" fill="red" clip-path="url(#c)"/>`)
+	assert.Equal(t, image.Rect(10, 10, 40, 40), paintedBounds(img, red))
+	assert.Equal(t, uint8(0), at(img, 25, 25).A)
+}
+
+func TestClipPathSelfReference(t *testing.T) {
+	cases := map[string]string{
+		"direct":   `<clipPath id="c" clip-path="url(#c)"><rect width="20" height="20"/></clipPath>`,
+		"child":    `<clipPath id="c"><rect width="20" height="20" clip-path="url(#c)"/></clipPath>`,
+		"indirect": `<clipPath id="c" clip-path="url(#d)"><rect width="20" height="20"/></clipPath><clipPath id="d" clip-path="url(#c)"><rect width="20" height="20"/></clipPath>`,
+	}
+	for name, defs := range cases {
+		t.Run(name, func(t *testing.T) {
+			// An element that references a clipPath in error is not rendered.
+			img := renderElements(t, 40, 40, defs+`<rect width="40" height="40" fill="red" clip-path="url(#c)"/>`)
+			assert.True(t, paintedBounds(img, red).Empty())
+		})
+	}
+}
+
+func TestClipRule(t *testing.T) {
+	// The second square lies inside the first and winds in the same direction.
+	const squares = "M 0 0 H 40 V 40 H 0 Z M 10 10 H 30 V 30 H 10 Z"
+	const clipped = `<rect width="40" height="40" fill="red" clip-path="url(#c)"/>`
+
+	cases := []struct {
+		name, defs string
+		hole       bool
+	}{
+		{"nonzero", `<clipPath id="c"><path d="` + squares + `"/></clipPath>`, false},
+		{"evenodd", `<clipPath id="c"><path d="` + squares + `" clip-rule="evenodd"/></clipPath>`, true},
+		{"inherited", `<clipPath id="c" clip-rule="evenodd"><path d="` + squares + `"/></clipPath>`, true},
+		{"fill-rule", `<clipPath id="c"><path d="` + squares + `" fill-rule="evenodd"/></clipPath>`, false},
+		{"ancestor", `<g clip-rule="evenodd"><clipPath id="c"><path d="` + squares + `"/></clipPath></g>`, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			img := renderElements(t, 40, 40, c.defs+clipped)
+			assert.Equal(t, uint8(255), at(img, 5, 5).A)
+			if c.hole {
+				assert.Equal(t, uint8(0), at(img, 20, 20).A)
+			} else {
+				assert.Equal(t, uint8(255), at(img, 20, 20).A)
+			}
+		})
+	}
+}
+
+func TestClipBasicShape(t *testing.T) {
+	// Shapes are positioned relative to the element's 30x30 reference box at (10, 10). The
+	// expected bounds are those of the fully opaque pixels, so they exclude antialiased
+	// edges.
+	cases := []struct {
+		clip     string
+		expected image.Rectangle
+		inside   []image.Point
+		outside  []image.Point
+	}{
+		{"inset(5px 10px)", image.Rect(20, 15, 30, 35), nil, nil},
+		{"inset(10%)", image.Rect(13, 13, 37, 37), nil, nil},
+		{"circle(5px at 10px 10px)", image.Rect(16, 16, 24, 24), []image.Point{{20, 20}}, []image.Point{{15, 15}}},
+		{"circle()", image.Rect(11, 11, 39, 39), []image.Point{{25, 25}}, []image.Point{{11, 11}}},
+		{"ellipse(10px 5px)", image.Rect(16, 21, 34, 29), []image.Point{{25, 25}}, []image.Point{{16, 21}}},
+		{"polygon(0 0, 100% 0, 0 100%)", image.Rect(10, 10, 39, 39), []image.Point{{12, 12}}, []image.Point{{35, 35}}},
+		{"view-box", image.Rect(10, 10, 40, 40), nil, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.clip, func(t *testing.T) {
+			img := renderElements(t, 40, 40, `<rect x="10" y="10" width="30" height="30" fill="red" clip-path="`+c.clip+`"/>`)
+			assert.Equal(t, c.expected, paintedBounds(img, red))
+			for _, p := range c.inside {
+				assert.Equal(t, uint8(255), at(img, p.X, p.Y).A, "%v", p)
+			}
+			for _, p := range c.outside {
+				assert.Equal(t, uint8(0), at(img, p.X, p.Y).A, "%v", p)
+			}
+		})
+	}
+}
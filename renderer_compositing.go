@@ -0,0 +1,245 @@
+package svg
+
+import (
+	"image"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// See https://www.w3.org/TR/compositing-1/ for the definitions of the blend modes and
+// compositing operators implemented here.
+
+// blendChannel applies a separable blend mode to a single pair of non-premultiplied color
+// components, where cb is the backdrop and cs is the source.
+func blendChannel(mode string, cb, cs float64) float64 {
+	switch mode {
+	case "multiply":
+		return cb * cs
+	case "screen":
+		return cb + cs - cb*cs
+	case "overlay":
+		return blendChannel("hard-light", cs, cb)
+	case "darken":
+		return math.Min(cb, cs)
+	case "lighten":
+		return math.Max(cb, cs)
+	case "color-dodge":
+		switch {
+		case cb == 0:
+			return 0
+		case cs == 1:
+			return 1
+		default:
+			return math.Min(1, cb/(1-cs))
+		}
+	case "color-burn":
+		switch {
+		case cb == 1:
+			return 1
+		case cs == 0:
+			return 0
+		default:
+			return 1 - math.Min(1, (1-cb)/cs)
+		}
+	case "hard-light":
+		if cs <= 0.5 {
+			return cb * 2 * cs
+		}
+		return blendChannel("screen", cb, 2*cs-1)
+	case "soft-light":
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+	case "difference":
+		return math.Abs(cb - cs)
+	case "exclusion":
+		return cb + cs - 2*cb*cs
+	default:
+		return cs
+	}
+}
+
+func lum(c [3]float64) float64 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+func clipColor(c [3]float64) [3]float64 {
+	l := lum(c)
+	n := math.Min(c[0], math.Min(c[1], c[2]))
+	x := math.Max(c[0], math.Max(c[1], c[2]))
+	for i := range c {
+		if n < 0 {
+			c[i] = l + (c[i]-l)*l/(l-n)
+		}
+		if x > 1 {
+			c[i] = l + (c[i]-l)*(1-l)/(x-l)
+		}
+	}
+	return c
+}
+
+func setLum(c [3]float64, l float64) [3]float64 {
+	d := l - lum(c)
+	return clipColor([3]float64{c[0] + d, c[1] + d, c[2] + d})
+}
+
+func sat(c [3]float64) float64 {
+	return math.Max(c[0], math.Max(c[1], c[2])) - math.Min(c[0], math.Min(c[1], c[2]))
+}
+
+func setSat(c [3]float64, s float64) [3]float64 {
+	max, min := 0, 0
+	for i := range c {
+		if c[i] > c[max] {
+			max = i
+		}
+		if c[i] < c[min] {
+			min = i
+		}
+	}
+	if max == min {
+		return [3]float64{}
+	}
+	mid := 3 - max - min
+
+	var result [3]float64
+	result[mid] = (c[mid] - c[min]) * s / (c[max] - c[min])
+	result[max] = s
+	return result
+}
+
+// blendColor applies a blend mode to a pair of non-premultiplied colors, where cb is the
+// backdrop and cs is the source.
+func blendColor(mode string, cb, cs [3]float64) [3]float64 {
+	switch mode {
+	case "hue":
+		return setLum(setSat(cs, sat(cb)), lum(cb))
+	case "saturation":
+		return setLum(setSat(cb, sat(cs)), lum(cb))
+	case "color":
+		return setLum(cs, lum(cb))
+	case "luminosity":
+		return setLum(cb, lum(cs))
+	default:
+		return [3]float64{blendChannel(mode, cb[0], cs[0]), blendChannel(mode, cb[1], cs[1]), blendChannel(mode, cb[2], cs[2])}
+	}
+}
+
+// blendPixel blends the premultiplied source color s with the premultiplied backdrop color b
+// using the given blend mode and composites the result using the source-over operator.
+func blendPixel(mode string, b, s [4]float64) [4]float64 {
+	as, ab := s[3], b[3]
+	ao := as + ab - as*ab
+
+	if mode == "normal" || mode == "" || as == 0 || ab == 0 {
+		return [4]float64{s[0] + b[0]*(1-as), s[1] + b[1]*(1-as), s[2] + b[2]*(1-as), ao}
+	}
+
+	cb := [3]float64{b[0] / ab, b[1] / ab, b[2] / ab}
+	cs := [3]float64{s[0] / as, s[1] / as, s[2] / as}
+	blended := blendColor(mode, cb, cs)
+
+	var result [4]float64
+	for i := range blended {
+		result[i] = s[i]*(1-ab) + b[i]*(1-as) + as*ab*blended[i]
+	}
+	result[3] = ao
+	return result
+}
+
+// drawImage composites the premultiplied source image onto the destination image using the
+// source-over operator. If mask is not nil, the source is first multiplied by the mask. The
+// images and the mask must have the same bounds.
+func drawImage(dst, src *image.RGBA, mask *image.Alpha) {
+	for i := 0; i < len(src.Pix); i += 4 {
+		m := uint32(255)
+		if mask != nil {
+			m = uint32(mask.Pix[i/4])
+		}
+
+		sa := uint32(src.Pix[i+3]) * m / 255
+		if sa == 0 {
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			s := uint32(src.Pix[i+c]) * m / 255
+			d := uint32(dst.Pix[i+c])
+			dst.Pix[i+c] = uint8(s + (d*(255-sa)+127)/255)
+		}
+	}
+}
+
+// blendImage blends the premultiplied source image with the destination image using the given
+// blend mode and composites the result onto the destination using the source-over operator.
+// If mask is not nil, the source is first multiplied by the mask. The images and the mask must
+// have the same bounds.
+func blendImage(dst, src *image.RGBA, mask *image.Alpha, mode string) {
+	for i := 0; i < len(src.Pix); i += 4 {
+		m := 1.0
+		if mask != nil {
+			m = float64(mask.Pix[i/4]) / 255
+		}
+		if src.Pix[i+3] == 0 || m == 0 {
+			continue
+		}
+
+		var s, b [4]float64
+		for c := 0; c < 4; c++ {
+			s[c] = float64(src.Pix[i+c]) / 255 * m
+			b[c] = float64(dst.Pix[i+c]) / 255
+		}
+		result := blendPixel(mode, b, s)
+		for c := 0; c < 4; c++ {
+			dst.Pix[i+c] = uint8(math.Max(0, math.Min(1, result[c]))*255 + 0.5)
+		}
+	}
+}
+
+// renderIsolated renders graphics onto a new transparent buffer and blends and composites the
+// result with the context's contents using the given blend mode. Before compositing, the
+// buffer is multiplied by the given opacity and, if it is not nil, the given mask. The current
+// clip region is applied while compositing rather than while rendering.
+func (r *renderer) renderIsolated(ctx *gg.Context, opacity float64, mask *image.Alpha, mode string, render func(dc *gg.Context) error) error {
+	dc := gg.NewContext(ctx.Width(), ctx.Height())
+	applyMatrix(dc, currentMatrix(ctx))
+
+	clips := r.clips
+	r.clips = nil
+	err := render(dc)
+	r.clips = clips
+	if err != nil {
+		return err
+	}
+
+	if n := len(r.clips); n != 0 {
+		if mask == nil {
+			mask = r.clips[n-1]
+		} else {
+			mask = intersectMasks(mask, r.clips[n-1])
+		}
+	}
+	if opacity < 1 {
+		scaled := image.NewAlpha(dc.Image().Bounds())
+		for i := range scaled.Pix {
+			a := 255.0
+			if mask != nil {
+				a = float64(mask.Pix[i])
+			}
+			scaled.Pix[i] = uint8(a*opacity + 0.5)
+		}
+		mask = scaled
+	}
+
+	if mode == "normal" {
+		drawImage(ctx.Image().(*image.RGBA), dc.Image().(*image.RGBA), mask)
+	} else {
+		blendImage(ctx.Image().(*image.RGBA), dc.Image().(*image.RGBA), mask, mode)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package svg
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertRGB(t *testing.T, expected, actual [3]float64) {
+	t.Helper()
+	for i := range expected {
+		assert.InDelta(t, expected[i], actual[i], 1e-3, "%v: %v", i, actual)
+	}
+}
+
+func TestBlendSoftLight(t *testing.T) {
+	cases := []struct {
+		cb, cs, expected float64
+	}{
+		{0.5, 0.25, 0.375},
+		{0.2, 0.75, 0.324},
+		{0.64, 0.75, 0.72},
+		{0.25, 0.75, 0.375},
+		{0, 1, 0},
+		{1, 0, 1},
+		{0.3, 0.5, 0.3},
+	}
+	for _, c := range cases {
+		assert.InDelta(t, c.expected, blendChannel("soft-light", c.cb, c.cs), 1e-9, "%v, %v", c.cb, c.cs)
+	}
+}
+
+func TestBlendNonSeparable(t *testing.T) {
+	red, blue := [3]float64{1, 0, 0}, [3]float64{0, 0, 1}
+	gray, white, black := [3]float64{0.5, 0.5, 0.5}, [3]float64{1, 1, 1}, [3]float64{}
+
+	cases := []struct {
+		mode     string
+		cb, cs   [3]float64
+		expected [3]float64
+	}{
+		// The source's hue with the backdrop's saturation and luminosity. The luminosity of
+		// red is 0.3, so the result must be clipped.
+		{"hue", red, blue, [3]float64{0.2135, 0.2135, 1}},
+		{"hue", gray, blue, gray},
+		{"saturation", red, gray, [3]float64{0.3, 0.3, 0.3}},
+		{"saturation", [3]float64{0.2, 0.6, 0.4}, red, [3]float64{0, 0.7101, 0.3550}},
+		{"color", gray, red, [3]float64{1, 0.2857, 0.2857}},
+		{"color", black, red, black},
+		{"luminosity", red, white, white},
+		{"luminosity", blue, black, black},
+		{"luminosity", red, [3]float64{0.2, 0.2, 0.2}, [3]float64{0.6667, 0, 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.mode, func(t *testing.T) {
+			assertRGB(t, c.expected, blendColor(c.mode, c.cb, c.cs))
+		})
+	}
+}
+
+func TestSetSat(t *testing.T) {
+	assertRGB(t, [3]float64{0, 0.5, 0.25}, setSat([3]float64{0.2, 0.6, 0.4}, 0.5))
+	assertRGB(t, [3]float64{0.5, 0.5, 0}, setSat([3]float64{1, 1, 0}, 0.5))
+	assertRGB(t, [3]float64{}, setSat([3]float64{0.7, 0.7, 0.7}, 0.5))
+}
+
+func TestClipColor(t *testing.T) {
+	// Colors within gamut are unchanged.
+	assertRGB(t, [3]float64{0.2, 0.4, 0.6}, clipColor([3]float64{0.2, 0.4, 0.6}))
+
+	// Out-of-gamut colors are pulled toward their luminosity, which is preserved.
+	c := clipColor([3]float64{1.2, 0.2, 0.2})
+	assertRGB(t, [3]float64{1, 0.2857, 0.2857}, c)
+	assert.InDelta(t, 0.5, lum(c), 1e-9)
+
+	c = clipColor([3]float64{-0.2, 0.3, 0.6})
+	assert.InDelta(t, 0, c[0], 1e-9)
+	assert.InDelta(t, lum([3]float64{-0.2, 0.3, 0.6}), lum(c), 1e-9)
+}
+
+func TestBlendPixel(t *testing.T) {
+	// With a translucent source, the blended color is mixed with the source and the
+	// backdrop in proportion to their alphas.
+	b := [4]float64{1, 0, 0, 1}
+	s := [4]float64{0, 0, 0.5, 0.5}
+	p := blendPixel("hue", b, s)
+	assert.InDelta(t, 0.5+0.5*0.2135, p[0], 1e-3)
+	assert.InDelta(t, 0.5*0.2135, p[1], 1e-3)
+	assert.InDelta(t, 0.5, p[2], 1e-3)
+	assert.InDelta(t, 1, p[3], 1e-9)
+}
+
+func TestMixBlendModeNonSeparable(t *testing.T) {
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+		<rect width="10" height="10" fill="red"/>
+		<rect width="10" height="10" fill="blue" mix-blend-mode="hue"/>
+	</svg>`)
+
+	assertColor(t, img, 5, 5, color.NRGBA{R: 54, G: 54, B: 255, A: 255})
+}
+
+func TestGroupOpacity(t *testing.T) {
+	// The children of a group with opacity are composited with each other before the group is
+	// composited with its backdrop, so their overlap is not blended.
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="30" height="10">
+		<g opacity="0.5">
+			<rect width="20" height="10" fill="red"/>
+			<rect x="10" width="20" height="10" fill="blue"/>
+		</g>
+	</svg>`)
+
+	assertColor(t, img, 5, 5, color.NRGBA{R: 255, A: 128})
+	assertColor(t, img, 15, 5, color.NRGBA{B: 255, A: 128})
+	assertColor(t, img, 25, 5, color.NRGBA{B: 255, A: 128})
+}
+
+func TestGroupIsolation(t *testing.T) {
+	cases := []struct {
+		isolation string
+		expected  color.NRGBA
+	}{
+		{"auto", color.NRGBA{G: 255, A: 255}},
+		{"isolate", color.NRGBA{G: 255, B: 255, A: 255}},
+	}
+	for _, c := range cases {
+		t.Run(c.isolation, func(t *testing.T) {
+			// The child multiplies with the yellow backdrop unless its group is isolated.
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+				<rect width="10" height="10" fill="yellow"/>
+				<g isolation="`+c.isolation+`">
+					<rect width="10" height="10" fill="cyan" mix-blend-mode="multiply"/>
+				</g>
+			</svg>`)
+
+			assertColor(t, img, 5, 5, c.expected)
+		})
+	}
+}
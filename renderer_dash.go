@@ -0,0 +1,52 @@
+package svg
+
+import "math"
+
+// computeDashes computes the dash array and dash offset in user space for the element at the
+// top of the stack using its stroke-dasharray and stroke-dashoffset properties. length is the
+// length of the element's geometry in user space, and pathLength is the value of the element's
+// pathLength attribute. If pathLength is positive, dash lengths and offsets are scaled by
+// length / pathLength. If the element's stroke is not dashed, the result is empty. Otherwise,
+// the dash array has an even number of elements and the offset is non-negative.
+func (r *renderer) computeDashes(pathLength, length float64) ([]float64, float64) {
+	da := r.getStrokeDasharray()
+	if da == nil || len(da.Values) == 0 {
+		return nil, 0
+	}
+
+	// A dash array with negative values or a zero sum is rendered as if it were none.
+	dashes, total := make([]float64, 0, 2*len(da.Values)), 0.0
+	for _, v := range da.Values {
+		d := r.computeLengthPercentage(r.diag(), v)
+		if d < 0 {
+			return nil, 0
+		}
+		dashes, total = append(dashes, d), total+d
+	}
+	if total == 0 {
+		return nil, 0
+	}
+
+	// An odd number of values is repeated to yield an even number of values.
+	if len(dashes)%2 == 1 {
+		dashes, total = append(dashes, dashes...), 2*total
+	}
+
+	offset := 0.0
+	if o := r.getStrokeDashoffset(); o != nil {
+		offset = r.computeLengthPercentage(r.diag(), *o)
+	}
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+
+	if pathLength > 0 {
+		scale := length / pathLength
+		for i := range dashes {
+			dashes[i] *= scale
+		}
+		offset *= scale
+	}
+	return dashes, offset
+}
@@ -0,0 +1,66 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dashes renders a dashed horizontal line across a 60x60 viewport, whose diagonal is 60 units
+// long, and returns the dash pattern of the line: painted pixels are marked with '#' and
+// unpainted pixels with '.'.
+func dashes(t *testing.T, attrs string) string {
+	img := renderElements(t, 60, 60, `<path d="M 0 20 H 60" fill="none" stroke="red" stroke-width="2" `+attrs+`/>`)
+
+	var b strings.Builder
+	for x := 0; x < 60; x++ {
+		if at(img, x, 19).A > 128 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+func TestStrokeDashes(t *testing.T) {
+	cases := []struct {
+		name, attrs, expected string
+	}{
+		{"solid", ``, "############################################################"},
+		{"dasharray", `stroke-dasharray="10 5"`, "##########.....##########.....##########.....##########....."},
+		{"dashoffset", `stroke-dasharray="10 5" stroke-dashoffset="5"`, "#####.....##########.....##########.....##########.....#####"},
+		{"offset in gap", `stroke-dasharray="10 5" stroke-dashoffset="12"`, "...##########.....##########.....##########.....##########.."},
+		{"offset past pattern", `stroke-dasharray="10 5" stroke-dashoffset="35"`, "#####.....##########.....##########.....##########.....#####"},
+
+		// A negative offset moves the pattern forwards along the path.
+		{"negative dashoffset", `stroke-dasharray="10 5" stroke-dashoffset="-5"`, ".....##########.....##########.....##########.....##########"},
+
+		// An odd number of values is repeated.
+		{"odd", `stroke-dasharray="10 5 5"`, "##########.....#####..........#####.....##########.....#####"},
+		{"single", `stroke-dasharray="10"`, "##########..........##########..........##########.........."},
+
+		// Percentages refer to the diagonal of the viewport.
+		{"percentages", `stroke-dasharray="10% 5%" stroke-dashoffset="5%"`, "###...######...######...######...######...######...######..."},
+
+		// Dash arrays with negative values or a zero sum are ignored.
+		{"negative", `stroke-dasharray="10 -5"`, "############################################################"},
+		{"zero", `stroke-dasharray="0 0"`, "############################################################"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, dashes(t, c.attrs))
+		})
+	}
+}
+
+func TestStrokeDashesPathLength(t *testing.T) {
+	// The path is 60 units long, so with a pathLength of 30, dashes and offsets are doubled.
+	assert.Equal(t, dashes(t, `stroke-dasharray="10 5"`), dashes(t, `stroke-dasharray="5 2.5" pathLength="30"`))
+	assert.Equal(t, dashes(t, `stroke-dasharray="10 5" stroke-dashoffset="5"`), dashes(t, `stroke-dasharray="5 2.5" stroke-dashoffset="2.5" pathLength="30"`))
+	assert.Equal(t, dashes(t, `stroke-dasharray="10 5" stroke-dashoffset="-5"`), dashes(t, `stroke-dasharray="5 2.5" stroke-dashoffset="-2.5" pathLength="30"`))
+
+	// Percentages are scaled as well.
+	assert.Equal(t, dashes(t, `stroke-dasharray="12 6"`), dashes(t, `stroke-dasharray="10% 5%" pathLength="30"`))
+}
@@ -0,0 +1,273 @@
+package svg
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// magenta is the sum of red and blue.
+var magenta = color.NRGBA{R: 255, B: 255, A: 255}
+
+func TestFilterFlood(t *testing.T) {
+	img := renderElements(t, 20, 20,
+		`<filter id="f" x="0" y="0" width="1" height="1"><feFlood flood-color="blue" flood-opacity="0.5"/></filter>
+		<rect x="5" y="5" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+	assertColor(t, img, 10, 10, color.NRGBA{B: 255, A: 128})
+	assertColor(t, img, 2, 2, transparent)
+	assertColor(t, img, 17, 17, transparent)
+}
+
+func TestFilterOffset(t *testing.T) {
+	img := renderElements(t, 20, 20,
+		`<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="20" height="20"><feOffset dx="5" dy="3"/></filter>
+		<rect width="5" height="5" fill="red" filter="url(#f)"/>`)
+
+	assertColor(t, img, 2, 2, transparent)
+	assertColor(t, img, 7, 5, red)
+	assertColor(t, img, 7, 9, transparent)
+}
+
+func TestFilterComposite(t *testing.T) {
+	// The source graphic covers [0, 10) and the flood covers [5, 15), so the columns sampled
+	// are in the source only, in both, in the flood only and in neither.
+	cases := []struct {
+		operator string
+		expected [4]color.NRGBA
+	}{
+		{`operator="over"`, [4]color.NRGBA{red, red, blue, transparent}},
+		{`operator="in"`, [4]color.NRGBA{transparent, red, transparent, transparent}},
+		{`operator="out"`, [4]color.NRGBA{red, transparent, transparent, transparent}},
+		{`operator="atop"`, [4]color.NRGBA{transparent, red, blue, transparent}},
+		{`operator="xor"`, [4]color.NRGBA{red, transparent, blue, transparent}},
+		{`operator="lighter"`, [4]color.NRGBA{red, magenta, blue, transparent}},
+		{`operator="arithmetic" k2="0.5" k3="0.5"`, [4]color.NRGBA{
+			{R: 255, A: 128},
+			{R: 128, B: 128, A: 255},
+			{B: 255, A: 128},
+			transparent,
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.operator, func(t *testing.T) {
+			img := renderElements(t, 20, 20,
+				`<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="20" height="20" color-interpolation-filters="sRGB">
+					<feFlood flood-color="blue" x="5" width="10" result="flood"/>
+					<feComposite in="SourceGraphic" in2="flood" `+c.operator+`/>
+				</filter>
+				<rect width="10" height="20" fill="red" filter="url(#f)"/>`)
+
+			for i, expected := range c.expected {
+				assertColor(t, img, 2+5*i, 10, expected)
+			}
+		})
+	}
+}
+
+func TestFilterMerge(t *testing.T) {
+	img := renderElements(t, 20, 20,
+		`<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="20" height="20">
+			<feFlood flood-color="blue" x="5" width="10" result="flood"/>
+			<feMerge><feMergeNode in="flood"/><feMergeNode in="SourceGraphic"/></feMerge>
+		</filter>
+		<rect width="10" height="20" fill="red" filter="url(#f)"/>`)
+
+	assertColor(t, img, 2, 10, red)
+	assertColor(t, img, 7, 10, red)
+	assertColor(t, img, 12, 10, blue)
+	assertColor(t, img, 17, 10, transparent)
+}
+
+func TestFilterColorMatrix(t *testing.T) {
+	cases := []struct {
+		attrs    string
+		expected color.NRGBA
+	}{
+		{`type="matrix" values="0 0 1 0 0 0 1 0 0 0 1 0 0 0 0 0 0 0 1 0"`, blue},
+		{`type="saturate" values="0"`, color.NRGBA{R: 54, G: 54, B: 54, A: 255}},
+		{`type="hueRotate" values="180"`, color.NRGBA{G: 109, B: 109, A: 255}},
+		{`type="luminanceToAlpha"`, color.NRGBA{A: 54}},
+		{``, red},
+	}
+	for _, c := range cases {
+		t.Run(c.attrs, func(t *testing.T) {
+			img := renderElements(t, 20, 20,
+				`<filter id="f" color-interpolation-filters="sRGB"><feColorMatrix `+c.attrs+`/></filter>
+				<rect x="5" y="5" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+			assertColor(t, img, 10, 10, c.expected)
+		})
+	}
+}
+
+func TestFilterColorInterpolation(t *testing.T) {
+	cases := []struct {
+		attrs string
+		red   uint8
+	}{
+		{``, 188},
+		{`color-interpolation-filters="linearRGB"`, 188},
+		{`color-interpolation-filters="sRGB"`, 128},
+	}
+	for _, c := range cases {
+		t.Run(c.attrs, func(t *testing.T) {
+			img := renderElements(t, 20, 20,
+				`<filter id="f"><feColorMatrix `+c.attrs+` values="0.5 0 0 0 0 0 1 0 0 0 0 0 1 0 0 0 0 0 1 0"/></filter>
+				<rect x="5" y="5" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+			assertColor(t, img, 10, 10, color.NRGBA{R: c.red, A: 255})
+		})
+	}
+}
+
+func TestFilterDropShadow(t *testing.T) {
+	img := renderElements(t, 20, 20,
+		`<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="20" height="20">
+			<feDropShadow dx="6" dy="6" stdDeviation="0" flood-color="blue"/>
+		</filter>
+		<rect x="2" y="2" width="6" height="6" fill="red" filter="url(#f)"/>`)
+
+	assertColor(t, img, 5, 5, red)
+	assertColor(t, img, 12, 12, blue)
+	assertColor(t, img, 16, 16, transparent)
+}
+
+func TestFilterGaussianBlur(t *testing.T) {
+	// Standard deviations below 2 use a true Gaussian kernel; the others use box blurs with
+	// even and odd box sizes.
+	for _, stdDeviation := range []string{"1", "2", "2.5", "3"} {
+		t.Run(stdDeviation, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="40">
+				<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="40" height="40">
+					<feGaussianBlur stdDeviation="`+stdDeviation+` 0"/>
+				</filter>
+				<rect x="15" y="0" width="10" height="40" filter="url(#f)"/>
+			</svg>`)
+
+			// The blur spreads the rectangle's coverage symmetrically without changing its
+			// total.
+			total := 0
+			for x := 0; x < 40; x++ {
+				a := int(at(img, x, 20).A)
+				total += a
+				assert.InDelta(t, a, int(at(img, 39-x, 20).A), 2, "%v", x)
+				if x < 20 {
+					assert.LessOrEqual(t, a, int(at(img, x+1, 20).A)+1, "%v", x)
+				}
+			}
+			assert.InDelta(t, 10*255, total, 40)
+
+			assert.Greater(t, int(at(img, 14, 20).A), 0)
+			assert.Less(t, int(at(img, 15, 20).A), 255)
+			assert.Equal(t, uint8(0), at(img, 4, 20).A)
+
+			// Blurring is disabled along the y axis.
+			assert.Equal(t, at(img, 16, 0), at(img, 16, 20))
+		})
+	}
+}
+
+func TestFilterRegion(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		// The default filter region extends 10% beyond the bounding box on each side.
+		img := renderElements(t, 20, 20, `<filter id="f"><feFlood flood-color="blue"/></filter>
+		<rect x="5" y="5" width="10" height="10" filter="url(#f)"/>`)
+
+		assertColor(t, img, 4, 10, blue)
+		assertColor(t, img, 15, 10, blue)
+		assertColor(t, img, 3, 10, transparent)
+		assertColor(t, img, 16, 10, transparent)
+	})
+
+	t.Run("objectBoundingBox", func(t *testing.T) {
+		img := renderElements(t, 20, 20, `<filter id="f" x="0.5" y="0" width="0.5" height="1"><feFlood flood-color="blue"/></filter>
+		<rect x="0" y="0" width="20" height="20" filter="url(#f)"/>`)
+
+		assertColor(t, img, 8, 10, transparent)
+		assertColor(t, img, 12, 10, blue)
+	})
+
+	t.Run("userSpaceOnUse", func(t *testing.T) {
+		img := renderElements(t, 20, 20, `<filter id="f" filterUnits="userSpaceOnUse" x="2" y="0" width="4" height="20"><feFlood flood-color="blue"/></filter>
+		<rect x="10" y="10" width="5" height="5" filter="url(#f)"/>`)
+
+		assertColor(t, img, 1, 10, transparent)
+		assertColor(t, img, 3, 10, blue)
+		assertColor(t, img, 7, 10, transparent)
+		assertColor(t, img, 12, 12, transparent)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		img := renderElements(t, 20, 20, `<filter id="f" width="0"><feFlood flood-color="blue"/></filter>
+		<rect x="5" y="5" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+		assertColor(t, img, 10, 10, transparent)
+	})
+}
+
+func TestFilterPrimitiveUnits(t *testing.T) {
+	t.Run("objectBoundingBox", func(t *testing.T) {
+		// Offsets and subregions are fractions of the bounding box.
+		img := renderElements(t, 20, 20,
+			`<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="20" height="20" primitiveUnits="objectBoundingBox">
+				<feOffset dx="0.5"/>
+			</filter>
+			<rect x="0" y="0" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+		assertColor(t, img, 2, 5, transparent)
+		assertColor(t, img, 7, 5, red)
+		assertColor(t, img, 12, 5, red)
+		assertColor(t, img, 17, 5, transparent)
+
+		img = renderElements(t, 20, 20,
+			`<filter id="f" filterUnits="userSpaceOnUse" x="0" y="0" width="20" height="20" primitiveUnits="objectBoundingBox">
+				<feFlood flood-color="blue" x="0.5" width="0.25"/>
+			</filter>
+			<rect x="4" y="4" width="8" height="8" filter="url(#f)"/>`)
+
+		assertColor(t, img, 7, 10, transparent)
+		assertColor(t, img, 9, 10, blue)
+		assertColor(t, img, 11, 10, transparent)
+	})
+
+	// Percentages refer to the filter region in both units.
+	for _, units := range []string{"userSpaceOnUse", "objectBoundingBox"} {
+		t.Run(units+" percentages", func(t *testing.T) {
+			img := renderElements(t, 20, 20,
+				`<filter id="f" filterUnits="userSpaceOnUse" x="4" y="0" width="16" height="20" primitiveUnits="`+units+`">
+					<feFlood flood-color="blue" x="50%" width="25%"/>
+				</filter>
+				<rect x="0" y="0" width="10" height="10" filter="url(#f)"/>`)
+
+			assertColor(t, img, 11, 10, transparent)
+			assertColor(t, img, 13, 10, blue)
+			assertColor(t, img, 15, 10, blue)
+			assertColor(t, img, 16, 10, transparent)
+		})
+	}
+}
+
+func TestFilterUnsupportedPrimitive(t *testing.T) {
+	// Unsupported primitives produce transparent black, which is the implicit input of the
+	// next primitive.
+	img := renderElements(t, 20, 20,
+		`<filter id="f">
+			<feTurbulence baseFrequency="0.05"/>
+			<feOffset dx="1"/>
+		</filter>
+		<rect x="5" y="5" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+	assertColor(t, img, 10, 10, transparent)
+
+	// Named results of unsupported primitives may be referenced.
+	img = renderElements(t, 20, 20,
+		`<filter id="f">
+			<feImage href="#missing" result="image"/>
+			<feComposite in="SourceGraphic" in2="image"/>
+		</filter>
+		<rect x="5" y="5" width="10" height="10" fill="red" filter="url(#f)"/>`)
+
+	assertColor(t, img, 10, 10, red)
+}
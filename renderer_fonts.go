@@ -1,10 +1,13 @@
 package svg
 
 import (
+	"errors"
+
 	_ "github.com/flopp/go-findfont"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
 
 	"golang.org/x/image/font/gofont/gobold"
 	"golang.org/x/image/font/gofont/gobolditalic"
@@ -32,7 +35,8 @@ type fontFamily struct {
 	weights []fontWeight
 }
 
-func (ff *fontFamily) newFace(weight font.Weight, style font.Style, points float64, hints font.Hinting) (font.Face, error) {
+// choose returns the font in the family that best matches the given weight and style.
+func (ff *fontFamily) choose(weight font.Weight, style font.Style) interface{} {
 	var chosen *fontWeight
 	for i := range ff.weights {
 		w := &ff.weights[i]
@@ -46,17 +50,22 @@ func (ff *fontFamily) newFace(weight font.Weight, style font.Style, points float
 	}
 
 	if style == font.StyleItalic {
-		return ff.newFaceFunc(chosen.italic, points, hints)
+		return chosen.italic
 	}
-	return ff.newFaceFunc(chosen.normal, points, hints)
+	return chosen.normal
+}
+
+func (ff *fontFamily) newFace(weight font.Weight, style font.Style, points float64, hints font.Hinting) (font.Face, error) {
+	return ff.newFaceFunc(ff.choose(weight, style), points, hints)
 }
 
 func newOpentypeFontFamily(weights []fontWeight) *fontFamily {
 	return &fontFamily{
 		newFaceFunc: func(font interface{}, points float64, hints font.Hinting) (font.Face, error) {
 			return opentype.NewFace(font.(*sfnt.Font), &opentype.FaceOptions{
-				Size:    points,
-				DPI:     72, // TODO: DPI
+				Size: points,
+				// Sizes are given in user units, and one point is one user unit at 72 DPI.
+				DPI:     72,
 				Hinting: hints,
 			})
 		},
@@ -153,3 +162,214 @@ func (r *renderer) resolveFontFamily(family *FontFamily) (*fontFamily, error) {
 
 	return goProportional, nil
 }
+
+// fontProperties resolves the font family, weight, and style of the element at the top of the
+// stack.
+func (r *renderer) fontProperties() (*fontFamily, font.Weight, font.Style, error) {
+	cssStyle := r.getFontStyle()
+	style := font.StyleNormal
+	if cssStyle == "italic" {
+		style = font.StyleItalic
+	}
+
+	cssWeight := r.getFontWeight()
+	weight := font.WeightNormal
+	switch cssWeight.Ident {
+	case "":
+		switch cssWeight.Number {
+		case 100:
+			weight = font.WeightThin
+		case 200:
+			weight = font.WeightExtraLight
+		case 300:
+			weight = font.WeightLight
+		case 400:
+			weight = font.WeightNormal
+		case 500:
+			weight = font.WeightMedium
+		case 600:
+			weight = font.WeightSemiBold
+		case 700:
+			weight = font.WeightBold
+		case 800:
+			weight = font.WeightExtraBold
+		case 900:
+			weight = font.WeightBlack
+		default:
+			weight = font.Weight(cssWeight.Number/100 - 4)
+		}
+	case "normal":
+		weight = font.WeightNormal
+	case "bold":
+		weight = font.WeightBold
+	case "bolder", "lighter":
+		return nil, 0, 0, errors.New("NYI: bolder/lighter")
+	}
+
+	// TODO: stretch
+
+	fontFamily, err := r.resolveFontFamily(r.getFontFamily())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return fontFamily, weight, style, nil
+}
+
+// fontFace returns a font face for the font properties of the element at the top of the stack.
+// The face's size is given in user units.
+func (r *renderer) fontFace() (font.Face, error) {
+	fontFamily, weight, style, err := r.fontProperties()
+	if err != nil {
+		return nil, err
+	}
+	return fontFamily.newFace(weight, style, r.computeFontSize(), font.HintingNone)
+}
+
+// textFont is a scalable font at a particular size that is used to lay out and render text.
+type textFont struct {
+	font *sfnt.Font
+	buf  sfnt.Buffer
+
+	// size is the font size in user units, and scale converts font units into user units.
+	size, scale float64
+
+	// ppem is the number of pixels per em at which glyphs are loaded. Glyphs are loaded in
+	// font units and then scaled so that their outlines are not quantized.
+	ppem fixed.Int26_6
+}
+
+// textFont returns a scalable font for the font properties of the element at the top of the
+// stack.
+func (r *renderer) textFont() (*textFont, error) {
+	fontFamily, weight, style, err := r.fontProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := fontFamily.choose(weight, style).(*sfnt.Font)
+	if !ok {
+		return nil, errors.New("NYI: non-scalable fonts")
+	}
+
+	size := r.computeFontSize()
+	upem := float64(f.UnitsPerEm())
+	return &textFont{
+		font:  f,
+		size:  size,
+		scale: size / upem,
+		ppem:  fixed.Int26_6(upem * 64),
+	}, nil
+}
+
+// glyphIndex returns the index of the glyph for the given rune. Runes that are not present in
+// the font map to the font's missing glyph.
+func (f *textFont) glyphIndex(r rune) sfnt.GlyphIndex {
+	i, err := f.font.GlyphIndex(&f.buf, r)
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// advance returns the advance width of the given glyph in user units.
+func (f *textFont) advance(g sfnt.GlyphIndex) float64 {
+	a, err := f.font.GlyphAdvance(&f.buf, g, f.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float64(a) / 64 * f.scale
+}
+
+// kern returns the kerning adjustment between the given glyphs in user units.
+func (f *textFont) kern(g0, g1 sfnt.GlyphIndex) float64 {
+	k, err := f.font.Kern(&f.buf, g0, g1, f.ppem, font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float64(k) / 64 * f.scale
+}
+
+// metrics returns the font's ascent and descent in user units. Both are positive.
+func (f *textFont) metrics() (ascent, descent float64) {
+	m, err := f.font.Metrics(&f.buf, f.ppem, font.HintingNone)
+	if err != nil {
+		return f.size, 0
+	}
+	return float64(m.Ascent) / 64 * f.scale, float64(m.Descent) / 64 * f.scale
+}
+
+// xHeight returns the font's x-height in user units. If the font does not specify its
+// x-height, it is approximated as half of the font size.
+func (f *textFont) xHeight() float64 {
+	m, err := f.font.Metrics(&f.buf, f.ppem, font.HintingNone)
+	if err != nil || m.XHeight <= 0 {
+		return f.size / 2
+	}
+	return float64(m.XHeight) / 64 * f.scale
+}
+
+// baseline returns the distance in user units from the font's alphabetic baseline up to the
+// given baseline. Fonts do not provide baseline tables, so the baselines are derived from the
+// font's metrics: the ideographic and text-after-edge baselines lie at the bottom of the em
+// box, the text-before-edge baseline lies at its top, the central baseline lies halfway
+// between the two, the middle baseline lies halfway up the x-height, and the hanging and
+// mathematical baselines lie at 80% and 50% of the ascent, respectively.
+func (f *textFont) baseline(name Ident) float64 {
+	ascent, descent := f.metrics()
+	switch name {
+	case "ideographic", "text-after-edge", "after-edge", "text-bottom", "bottom":
+		return -descent
+	case "text-before-edge", "before-edge", "text-top", "top":
+		return ascent
+	case "central", "center":
+		return (ascent - descent) / 2
+	case "middle":
+		return f.xHeight() / 2
+	case "hanging":
+		return ascent * 0.8
+	case "mathematical":
+		return ascent * 0.5
+	default:
+		return 0
+	}
+}
+
+// outline adds the outline of the given glyph to a path. Points in the glyph's coordinate
+// space, where the origin is on the baseline and y grows downwards, are mapped into user space
+// by transform.
+func (f *textFont) outline(path pathBuilder, g sfnt.GlyphIndex, transform func(x, y float64) (float64, float64)) {
+	segments, err := f.font.LoadGlyph(&f.buf, g, f.ppem, nil)
+	if err != nil {
+		return
+	}
+
+	point := func(p fixed.Point26_6) (float64, float64) {
+		return transform(float64(p.X)/64*f.scale, float64(p.Y)/64*f.scale)
+	}
+
+	open := false
+	for _, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if open {
+				path.ClosePath()
+			}
+			path.MoveTo(point(s.Args[0]))
+			open = true
+		case sfnt.SegmentOpLineTo:
+			path.LineTo(point(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := point(s.Args[0])
+			x2, y2 := point(s.Args[1])
+			path.QuadraticTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := point(s.Args[0])
+			x2, y2 := point(s.Args[1])
+			x3, y3 := point(s.Args[2])
+			path.CubicTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	if open {
+		path.ClosePath()
+	}
+}
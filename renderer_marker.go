@@ -0,0 +1,306 @@
+package svg
+
+import (
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// arcBuilder is implemented by pathBuilders that add elliptical arcs to a path as single
+// segments rather than as a sequence of curves.
+type arcBuilder interface {
+	ArcTo(x1, y1, rx, ry, xAxisRotation float64, largeArc, sweep bool, x2, y2 float64)
+}
+
+// markerVertex is a vertex of a path at which markers may be placed. in and out are the
+// directions of the path as it enters and leaves the vertex, if any.
+type markerVertex struct {
+	x, y          float64
+	in, out       gg.Point
+	hasIn, hasOut bool
+}
+
+// angle returns the orientation of a marker placed on the vertex with orient="auto", in
+// radians. The orientation bisects the incoming and outgoing directions of the path.
+func (v *markerVertex) angle() float64 {
+	switch {
+	case v.hasIn && v.hasOut:
+		in, out := math.Atan2(v.in.Y, v.in.X), math.Atan2(v.out.Y, v.out.X)
+		delta := math.Remainder(out-in, 2*math.Pi)
+		return in + delta/2
+	case v.hasIn:
+		return math.Atan2(v.in.Y, v.in.X)
+	case v.hasOut:
+		return math.Atan2(v.out.Y, v.out.X)
+	default:
+		return 0
+	}
+}
+
+// markerPath is a pathBuilder that records the vertices of a path for marker placement.
+type markerPath struct {
+	vertices []markerVertex
+
+	// start is the index of the vertex that begins the current subpath. pending is true if the
+	// last vertex begins a subpath that follows a closepath and has no segments yet.
+	start   int
+	pending bool
+}
+
+// segment adds a segment that ends at (x, y) to the path. (dx0, dy0) and (dx1, dy1) are the
+// directions of the segment at its start and end, respectively. Zero-length directions are
+// ignored.
+func (p *markerPath) segment(x, y, dx0, dy0, dx1, dy1 float64) {
+	if len(p.vertices) == 0 {
+		p.MoveTo(0, 0)
+	}
+	if prev := &p.vertices[len(p.vertices)-1]; dx0 != 0 || dy0 != 0 {
+		prev.out, prev.hasOut = gg.Point{X: dx0, Y: dy0}, true
+	}
+
+	v := markerVertex{x: x, y: y}
+	if dx1 != 0 || dy1 != 0 {
+		v.in, v.hasIn = gg.Point{X: dx1, Y: dy1}, true
+	}
+	p.vertices, p.pending = append(p.vertices, v), false
+}
+
+// current returns the current point of the path.
+func (p *markerPath) current() (float64, float64) {
+	if len(p.vertices) == 0 {
+		return 0, 0
+	}
+	v := p.vertices[len(p.vertices)-1]
+	return v.x, v.y
+}
+
+// direction returns the first non-zero vector in the given list of vectors.
+func direction(vectors ...float64) (float64, float64) {
+	for i := 0; i < len(vectors); i += 2 {
+		if vectors[i] != 0 || vectors[i+1] != 0 {
+			return vectors[i], vectors[i+1]
+		}
+	}
+	return 0, 0
+}
+
+func (p *markerPath) MoveTo(x, y float64) {
+	if p.pending {
+		p.vertices = p.vertices[:len(p.vertices)-1]
+	}
+	p.vertices, p.pending = append(p.vertices, markerVertex{x: x, y: y}), false
+	p.start = len(p.vertices) - 1
+}
+
+func (p *markerPath) LineTo(x, y float64) {
+	x0, y0 := p.current()
+	p.segment(x, y, x-x0, y-y0, x-x0, y-y0)
+}
+
+func (p *markerPath) QuadraticTo(x1, y1, x2, y2 float64) {
+	x0, y0 := p.current()
+	dx0, dy0 := direction(x1-x0, y1-y0, x2-x0, y2-y0)
+	dx1, dy1 := direction(x2-x1, y2-y1, x2-x0, y2-y0)
+	p.segment(x2, y2, dx0, dy0, dx1, dy1)
+}
+
+func (p *markerPath) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	x0, y0 := p.current()
+	dx0, dy0 := direction(x1-x0, y1-y0, x2-x0, y2-y0, x3-x0, y3-y0)
+	dx1, dy1 := direction(x3-x2, y3-y2, x3-x1, y3-y1, x3-x0, y3-y0)
+	p.segment(x3, y3, dx0, dy0, dx1, dy1)
+}
+
+// ArcTo adds an elliptical arc to the path as a single segment.
+func (p *markerPath) ArcTo(x1, y1, rx, ry, xAxisRotation float64, largeArc, sweep bool, x2, y2 float64) {
+	// Trace the arc separately in order to determine its directions at its endpoints.
+	arc := &markerPath{}
+	arc.MoveTo(x1, y1)
+	arcTo(arc, x1, y1, rx, ry, xAxisRotation, largeArc, sweep, x2, y2)
+	if len(arc.vertices) < 2 {
+		return
+	}
+
+	first, last := arc.vertices[0], arc.vertices[len(arc.vertices)-1]
+	p.segment(x2, y2, first.out.X, first.out.Y, last.in.X, last.in.Y)
+}
+
+func (p *markerPath) ClosePath() {
+	if len(p.vertices) == 0 {
+		return
+	}
+
+	start := p.vertices[p.start]
+	p.LineTo(start.x, start.y)
+
+	// The closing vertex leaves the path in the direction of the subpath's first segment, and
+	// the subpath's first vertex enters the path in the direction of the closing segment.
+	closing := &p.vertices[len(p.vertices)-1]
+	if !closing.hasIn {
+		prev := p.vertices[len(p.vertices)-2]
+		closing.in, closing.hasIn = prev.in, prev.hasIn
+	}
+	closing.out, closing.hasOut = start.out, start.hasOut
+	p.vertices[p.start].in, p.vertices[p.start].hasIn = closing.in, closing.hasIn
+
+	// Segments that follow a closepath begin a new subpath at the start of the closed subpath.
+	p.MoveTo(start.x, start.y)
+	p.pending = true
+}
+
+func (p *markerPath) NewSubPath() {}
+
+// renderMarkers renders the markers of the element at the top of the stack at the vertices of
+// the path traced by trace.
+func (r *renderer) renderMarkers(ctx *gg.Context, trace func(path pathBuilder)) error {
+	// Markers are not part of an element's clipping geometry.
+	if r.clipping {
+		return nil
+	}
+
+	lookup := func(u *URLIdent) (*Marker, error) {
+		if u == nil || u.URL == "" {
+			return nil, nil
+		}
+		target, err := r.lookup(u.URL)
+		if err != nil {
+			return nil, err
+		}
+		m, _ := target.(*Marker)
+		return m, nil
+	}
+	start, err := lookup(r.getMarkerStart())
+	if err != nil {
+		return err
+	}
+	mid, err := lookup(r.getMarkerMid())
+	if err != nil {
+		return err
+	}
+	end, err := lookup(r.getMarkerEnd())
+	if err != nil {
+		return err
+	}
+	if start == nil && mid == nil && end == nil {
+		return nil
+	}
+
+	path := &markerPath{}
+	trace(path)
+
+	// A trailing vertex that only begins a new subpath after a closepath is not a vertex of
+	// the path.
+	vertices := path.vertices
+	if path.pending {
+		vertices = vertices[:len(vertices)-1]
+	}
+
+	strokeWidth := 1.0
+	if sw := r.getStrokeWidth(); sw != nil {
+		strokeWidth = r.computeLengthPercentage(r.diag(), *sw)
+	}
+
+	for i := range vertices {
+		m := mid
+		switch i {
+		case 0:
+			m = start
+		case len(vertices) - 1:
+			m = end
+		}
+		if m == nil {
+			continue
+		}
+		if err := r.renderMarker(ctx, m, &vertices[i], i == 0, strokeWidth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderMarker renders a single instance of a marker at the given vertex. isStart is true if
+// the vertex is the first vertex of the path.
+func (r *renderer) renderMarker(ctx *gg.Context, m *Marker, v *markerVertex, isStart bool, strokeWidth float64) error {
+	// A marker that references itself, directly or indirectly, is in error and is not rendered.
+	if r.markers[m] {
+		return nil
+	}
+
+	markerWidth, markerHeight := 3.0, 3.0
+	if m.MarkerWidth != nil {
+		markerWidth = r.computeLengthPercentageNumber(r.width(), *m.MarkerWidth)
+	}
+	if m.MarkerHeight != nil {
+		markerHeight = r.computeLengthPercentageNumber(r.height(), *m.MarkerHeight)
+	}
+
+	// A zero-sized viewport disables rendering of the marker.
+	if markerWidth <= 0 || markerHeight <= 0 {
+		return nil
+	}
+
+	translateX, translateY, scaleX, scaleY := 0.0, 0.0, 1.0, 1.0
+	width, height := markerWidth, markerHeight
+	if vb := m.ViewBox; vb != nil {
+		if vb.Width == 0 || vb.Height == 0 {
+			// A zero-sized view box disables rendering of the element.
+			return nil
+		}
+		translateX, translateY, scaleX, scaleY = viewBoxTransform(vb, m.PreserveAspectRatio, 0, 0, markerWidth, markerHeight)
+		width, height = vb.Width, vb.Height
+	}
+
+	var angle float64
+	switch m.Orient.Ident {
+	case "auto":
+		angle = v.angle()
+	case "auto-start-reverse":
+		angle = v.angle()
+		if isStart {
+			angle += math.Pi
+		}
+	default:
+		angle = gg.Radians(m.Orient.Angle)
+	}
+
+	ctx.Push()
+	defer ctx.Pop()
+
+	// The marker's reference point, given in the marker's content coordinate system, is
+	// placed at the vertex.
+	ctx.Translate(v.x, v.y)
+	ctx.Rotate(angle)
+	if m.MarkerUnits != "userSpaceOnUse" {
+		ctx.Scale(strokeWidth, strokeWidth)
+	}
+	refX, refY := r.computeLength(m.RefX), r.computeLength(m.RefY)
+	ctx.Translate(-(translateX + refX*scaleX), -(translateY + refY*scaleY))
+
+	// Markers clip their contents to their viewport unless their overflow is visible.
+	switch m.Overflow {
+	case "visible", "auto":
+	default:
+		r.pushClip(ctx, pathMask(ctx, func(dc *gg.Context) {
+			dc.DrawRectangle(0, 0, markerWidth, markerHeight)
+		}))
+		defer r.popClip(ctx)
+	}
+
+	ctx.Translate(translateX, translateY)
+	ctx.Scale(scaleX, scaleY)
+
+	// The contents of a marker inherit properties from the marker and its ancestors rather than
+	// from the element that references the marker.
+	stack := r.stack
+	r.stack = append(r.ancestorStack(m), &element{Element: m, width: width, height: height})
+	if r.markers == nil {
+		r.markers = map[*Marker]bool{}
+	}
+	r.markers[m] = true
+	defer func() {
+		r.stack = stack
+		delete(r.markers, m)
+	}()
+
+	return r.renderCompositingGroup(ctx, m.Children)
+}
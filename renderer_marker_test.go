@@ -0,0 +1,138 @@
+package svg
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// markerAngles returns the positions and orient="auto" angles in degrees of the marker
+// vertices of the given path data.
+func markerAngles(t *testing.T, d string) ([]image.Point, []float64) {
+	commands, err := ParsePathCommands(d)
+	require.NoError(t, err)
+
+	path := &markerPath{}
+	tracePath(path, commands)
+	vertices := path.vertices
+	if path.pending {
+		vertices = vertices[:len(vertices)-1]
+	}
+
+	points, angles := make([]image.Point, len(vertices)), make([]float64, len(vertices))
+	for i, v := range vertices {
+		points[i] = image.Pt(int(math.Round(v.x)), int(math.Round(v.y)))
+		angles[i] = v.angle() * 180 / math.Pi
+	}
+	return points, angles
+}
+
+func TestMarkerAngles(t *testing.T) {
+	cases := []struct {
+		d      string
+		points []image.Point
+		angles []float64
+	}{
+		// Interior vertices bisect the incoming and outgoing directions.
+		{"M 0 0 L 10 0 L 10 10", []image.Point{{0, 0}, {10, 0}, {10, 10}}, []float64{0, 45, 90}},
+		{"M 0 0 L 10 0 L 0 0", []image.Point{{0, 0}, {10, 0}, {0, 0}}, []float64{0, 90, 180}},
+
+		// The first and last vertices of a closed subpath are joined.
+		{"M 0 0 L 10 0 L 10 10 Z", []image.Point{{0, 0}, {10, 0}, {10, 10}, {0, 0}}, []float64{-67.5, 45, 180 - 22.5, -67.5}},
+
+		// The ends of open subpaths use the directions of their only segments.
+		{"M 0 0 L 10 0 M 10 10 L 0 10", []image.Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, []float64{0, 0, 180, 180}},
+
+		// Curves use the directions of their control points at their ends.
+		{"M 0 0 C 0 10 10 10 10 0", []image.Point{{0, 0}, {10, 0}}, []float64{90, -90}},
+		{"M 0 0 Q 10 0 10 10", []image.Point{{0, 0}, {10, 10}}, []float64{0, 90}},
+	}
+	for _, c := range cases {
+		t.Run(c.d, func(t *testing.T) {
+			points, angles := markerAngles(t, c.d)
+			assert.Equal(t, c.points, points)
+			require.Len(t, angles, len(c.angles))
+			for i, expected := range c.angles {
+				assert.InDelta(t, expected, angles[i], 1e-6, "%v", i)
+			}
+		})
+	}
+}
+
+// pointer is marker content that extends 8 units in the positive x direction from the origin.
+const pointer = `<rect y="-1" width="8" height="2" fill="blue"/>`
+
+func TestMarkerOrient(t *testing.T) {
+	const marker = `markerUnits="userSpaceOnUse" overflow="visible" `
+
+	t.Run("auto", func(t *testing.T) {
+		img := renderElements(t, 40, 40, `<marker id="m" `+marker+`orient="auto">`+pointer+`</marker><path d="M 10 20 L 30 20" fill="none" marker-start="url(#m)" marker-end="url(#m)"/>`)
+		assert.Equal(t, image.Rect(10, 19, 38, 21), paintedBounds(img, blue))
+	})
+
+	t.Run("auto-start-reverse", func(t *testing.T) {
+		img := renderElements(t, 40, 40, `<marker id="m" `+marker+`orient="auto-start-reverse">`+pointer+`</marker><path d="M 10 20 L 30 20" fill="none" marker-start="url(#m)" marker-end="url(#m)"/>`)
+		assert.Equal(t, image.Rect(2, 19, 10, 21), paintedBounds(subImage(img, image.Rect(0, 0, 20, 40)), blue))
+		assert.Equal(t, image.Rect(30, 19, 38, 21), paintedBounds(subImage(img, image.Rect(20, 0, 40, 40)), blue))
+	})
+
+	t.Run("angle", func(t *testing.T) {
+		img := renderElements(t, 40, 40, `<marker id="m" `+marker+`orient="90">`+pointer+`</marker><path d="M 20 10 L 30 20" fill="none" marker-start="url(#m)"/>`)
+		assert.Equal(t, image.Rect(19, 10, 21, 18), paintedBounds(img, blue))
+	})
+
+	t.Run("vertex", func(t *testing.T) {
+		// The marker at the corner points down and to the right at 45 degrees.
+		img := renderElements(t, 40, 40, `<marker id="m" `+marker+`orient="auto">`+pointer+`</marker><path d="M 10 10 L 30 10 L 30 30" fill="none" marker-mid="url(#m)"/>`)
+		assert.Equal(t, uint8(255), at(img, 33, 13).A)
+		assert.Equal(t, uint8(0), at(img, 37, 10).A)
+		assert.Equal(t, uint8(0), at(img, 30, 17).A)
+	})
+
+	t.Run("subpath ends", func(t *testing.T) {
+		// The end of the first subpath points right, and the start of the second points left.
+		img := renderElements(t, 40, 40, `<marker id="m" `+marker+`orient="auto">`+pointer+`</marker><path d="M 2 10 L 10 10 M 30 30 L 20 30 L 10 30" fill="none" marker-mid="url(#m)"/>`)
+		assert.Equal(t, image.Rect(10, 9, 18, 11), paintedBounds(subImage(img, image.Rect(0, 0, 40, 20)), blue))
+		assert.Equal(t, image.Rect(22, 29, 30, 31), paintedBounds(subImage(img, image.Rect(21, 20, 40, 40)), blue))
+	})
+}
+
+func TestMarkerUnits(t *testing.T) {
+	// By default, marker contents are scaled by the stroke width.
+	img := renderElements(t, 40, 40, `<marker id="m" overflow="visible">`+pointer+`</marker><path d="M 10 20 L 20 20" fill="none" marker-end="url(#m)" stroke-width="2"/>`)
+	assert.Equal(t, image.Rect(20, 18, 36, 22), paintedBounds(img, blue))
+
+	img = renderElements(t, 40, 40, `<marker id="m" overflow="visible" markerUnits="userSpaceOnUse">`+pointer+`</marker><path d="M 10 20 L 20 20" fill="none" marker-end="url(#m)" stroke-width="2"/>`)
+	assert.Equal(t, image.Rect(20, 19, 28, 21), paintedBounds(img, blue))
+}
+
+func TestMarkerViewBox(t *testing.T) {
+	// The view box is scaled to the 10x10 viewport, and the reference point in view box units
+	// is placed at the vertex.
+	const marker = `markerUnits="userSpaceOnUse" markerWidth="10" markerHeight="10" viewBox="0 0 20 20" refX="10" refY="10"`
+
+	img := renderElements(t, 40, 40, `<marker id="m" `+marker+`><rect x="10" y="10" width="10" height="10" fill="blue"/></marker><path d="M 20 20 L 30 20" fill="none" marker-start="url(#m)"/>`)
+	assert.Equal(t, image.Rect(20, 20, 25, 25), paintedBounds(img, blue))
+
+	// Contents are clipped to the viewport.
+	img = renderElements(t, 40, 40, `<marker id="m" `+marker+`><rect x="-10" y="-10" width="40" height="40" fill="blue"/></marker><path d="M 20 20 L 30 20" fill="none" marker-start="url(#m)"/>`)
+	assert.Equal(t, image.Rect(15, 15, 25, 25), paintedBounds(img, blue))
+
+	// Without a view box, the reference point is given in the marker's content units.
+	img = renderElements(t, 40, 40, `<marker id="m" markerUnits="userSpaceOnUse" refX="2" refY="1" overflow="visible">`+pointer+`</marker><path d="M 20 20 L 30 20" fill="none" marker-start="url(#m)"/>`)
+	assert.Equal(t, image.Rect(18, 18, 26, 20), paintedBounds(img, blue))
+}
+
+func TestMarkerInheritance(t *testing.T) {
+	// The marker's contents inherit properties from the marker's ancestors rather than from
+	// the path that references the marker.
+	img := renderElements(t, 40, 40, `
+		<g fill="blue">
+			<marker id="m" markerUnits="userSpaceOnUse" overflow="visible"><rect y="-1" width="8" height="2"/></marker>
+		</g>
+		<path d="M 10 20 L 20 20" fill="red" marker-end="url(#m)"/>`)
+	assert.Equal(t, image.Rect(20, 19, 28, 21), paintedBounds(img, blue))
+}
@@ -0,0 +1,105 @@
+package svg
+
+import (
+	"image"
+
+	"github.com/fogleman/gg"
+)
+
+// computeMask renders the mask element referenced by the given URL into an alpha mask for the
+// element e. If the URL does not reference a mask element, computeMask returns nil. The
+// context's transform must be that of e's parent.
+func (r *renderer) computeMask(ctx *gg.Context, e Element, url string) (*image.Alpha, error) {
+	target, err := r.lookup(url)
+	if err != nil {
+		return nil, err
+	}
+
+	// References to missing elements or elements that are not masks are ignored.
+	m, ok := target.(*Mask)
+	if !ok {
+		return nil, nil
+	}
+
+	ctx.Push()
+	defer ctx.Pop()
+
+	dc := gg.NewContext(ctx.Width(), ctx.Height())
+
+	// A mask that references itself, directly or indirectly, is in error, and the element
+	// that references it is not rendered.
+	if !r.transform(ctx, e) || r.masks[m] {
+		return dc.AsMask(), nil
+	}
+
+	// The mask region defaults to the element's bounding box outset by 10% on each side.
+	bounds := r.elementBounds(e)
+	var x, y, width, height float64
+	if m.MaskUnits == UserSpaceOnUse {
+		x, y = r.computeGradientCoordinate(r.width(), -0.1, m.X), r.computeGradientCoordinate(r.height(), -0.1, m.Y)
+		width, height = r.computeGradientCoordinate(r.width(), 1.2, m.Width), r.computeGradientCoordinate(r.height(), 1.2, m.Height)
+	} else {
+		// An element without a bounding box is not rendered.
+		if !bounds.valid || bounds.width() == 0 || bounds.height() == 0 {
+			return dc.AsMask(), nil
+		}
+		x = bounds.minX + r.computeGradientCoordinate(1, -0.1, m.X)*bounds.width()
+		y = bounds.minY + r.computeGradientCoordinate(1, -0.1, m.Y)*bounds.height()
+		width = r.computeGradientCoordinate(1, 1.2, m.Width) * bounds.width()
+		height = r.computeGradientCoordinate(1, 1.2, m.Height) * bounds.height()
+	}
+
+	// A zero-sized mask region disables rendering of the element.
+	if width <= 0 || height <= 0 {
+		return dc.AsMask(), nil
+	}
+	region := pathMask(ctx, func(dc *gg.Context) {
+		dc.DrawRectangle(x, y, width, height)
+	})
+
+	applyMatrix(dc, currentMatrix(ctx))
+	if m.MaskContentUnits == ObjectBoundingBox {
+		if !bounds.valid || bounds.width() == 0 || bounds.height() == 0 {
+			return dc.AsMask(), nil
+		}
+		dc.Translate(bounds.minX, bounds.minY)
+		dc.Scale(bounds.width(), bounds.height())
+	}
+
+	// The mask's contents inherit properties from the mask and its ancestors rather than from
+	// the element being masked. The contents are rendered into their own context, so the active clip masks are
+	// set aside.
+	stack, clips, clipping := r.stack, r.clips, r.clipping
+	r.stack, r.clips, r.clipping = append(r.ancestorStack(m), &element{Element: m, width: r.width(), height: r.height()}), nil, false
+	if r.masks == nil {
+		r.masks = map[*Mask]bool{}
+	}
+	r.masks[m] = true
+	defer func() {
+		r.stack, r.clips, r.clipping = stack, clips, clipping
+		delete(r.masks, m)
+	}()
+
+	if err := r.renderCompositingGroup(dc, m.Children); err != nil {
+		return nil, err
+	}
+
+	var mask *image.Alpha
+	if m.MaskType == "alpha" {
+		mask = dc.AsMask()
+	} else {
+		mask = luminanceMask(dc.Image().(*image.RGBA))
+	}
+	return intersectMasks(mask, region), nil
+}
+
+// luminanceMask returns an alpha mask whose values are the luminance of the given image. The
+// image's colors are premultiplied, so the result also accounts for the image's alpha.
+func luminanceMask(im *image.RGBA) *image.Alpha {
+	mask := image.NewAlpha(im.Bounds())
+	for i := range mask.Pix {
+		p := im.Pix[i*4 : i*4+4]
+		mask.Pix[i] = uint8(0.2125*float64(p[0]) + 0.7154*float64(p[1]) + 0.0721*float64(p[2]) + 0.5)
+	}
+	return mask
+}
@@ -0,0 +1,82 @@
+package svg
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// everything is mask content that covers the canvas with white.
+const everything = `<rect x="-100" y="-100" width="200" height="200" fill="white"/>`
+
+func TestMaskType(t *testing.T) {
+	cases := []struct {
+		name, mask string
+		alpha      uint8
+	}{
+		{"luminance white", `<mask id="m">` + everything + `</mask>`, 255},
+		{"luminance gray", `<mask id="m"><rect width="40" height="40" fill="#808080"/></mask>`, 128},
+		{"luminance red", `<mask id="m"><rect width="40" height="40" fill="red"/></mask>`, 54},
+		{"luminance translucent", `<mask id="m"><rect width="40" height="40" fill="white" fill-opacity="0.5"/></mask>`, 128},
+		{"luminance black", `<mask id="m"><rect width="40" height="40" fill="black"/></mask>`, 0},
+		{"alpha black", `<mask id="m" mask-type="alpha"><rect width="40" height="40" fill="black"/></mask>`, 255},
+		{"alpha translucent", `<mask id="m" mask-type="alpha"><rect width="40" height="40" fill="red" fill-opacity="0.5"/></mask>`, 128},
+		{"alpha style", `<mask id="m" style="mask-type: alpha"><rect width="40" height="40" fill="black"/></mask>`, 255},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			img := renderElements(t, 40, 40, c.mask+`<rect x="10" y="10" width="20" height="20" fill="red" mask="url(#m)"/>`)
+
+			p := at(img, 20, 20)
+			assert.InDelta(t, int(c.alpha), int(p.A), 2)
+			if p.A != 0 {
+				assert.Equal(t, uint8(255), p.R)
+			}
+		})
+	}
+}
+
+func TestMaskUnits(t *testing.T) {
+	// The element's fill covers [10, 30) and its stroke covers [6, 34).
+	const masked = `<rect x="10" y="10" width="20" height="20" fill="red" stroke="red" stroke-width="8" mask="url(#m)"/>`
+
+	cases := []struct {
+		name, mask string
+		expected   image.Rectangle
+	}{
+		// The default mask region is the bounding box outset by 10% on each side.
+		{"default", `<mask id="m">` + everything + `</mask>`, image.Rect(8, 8, 32, 32)},
+		{"objectBoundingBox", `<mask id="m" x="0.25" y="0" width="0.5" height="2">` + everything + `</mask>`, image.Rect(15, 10, 25, 34)},
+		{"userSpaceOnUse", `<mask id="m" maskUnits="userSpaceOnUse" x="0" y="0" width="20" height="15">` + everything + `</mask>`, image.Rect(6, 6, 20, 15)},
+		{"userSpaceOnUse default", `<mask id="m" maskUnits="userSpaceOnUse">` + everything + `</mask>`, image.Rect(6, 6, 34, 34)},
+		{"maskContentUnits", `<mask id="m" maskContentUnits="objectBoundingBox"><rect width="0.5" height="0.25" fill="white"/></mask>`, image.Rect(10, 10, 20, 15)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			img := renderElements(t, 40, 40, c.mask+masked)
+			assert.Equal(t, c.expected, paintedBounds(img, red))
+		})
+	}
+
+	t.Run("empty region", func(t *testing.T) {
+		img := renderElements(t, 40, 40, `<mask id="m" width="0">`+everything+`</mask>`+masked)
+		assert.True(t, paintedBounds(img, red).Empty())
+	})
+}
+
+func TestMaskInheritance(t *testing.T) {
+	// The mask's contents inherit properties from the mask's ancestors rather than from the
+	// element being masked.
+	img := renderElements(t, 40, 40, `
+		<g fill="white"><mask id="m"><rect width="40" height="40"/></mask></g>
+		<g fill="black"><rect x="10" y="10" width="20" height="20" fill="red" mask="url(#m)"/></g>`)
+	assert.Equal(t, image.Rect(10, 10, 30, 30), paintedBounds(img, red))
+}
+
+func TestMaskSelfReference(t *testing.T) {
+	// A mask whose content references the mask is in error, and the element is not rendered.
+	img := renderElements(t, 40, 40, `<mask id="m"><rect width="40" height="40" fill="white" mask="url(#m)"/></mask>
+		<rect width="40" height="40" fill="red" mask="url(#m)"/>`)
+	assert.Equal(t, uint8(0), at(img, 20, 20).A)
+}
@@ -0,0 +1,441 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// gradientStop is a resolved gradient color stop.
+type gradientStop struct {
+	offset float64
+	color  color.RGBA
+}
+
+// computeGradientStops resolves a gradient's stops into a list of premultiplied colors with
+// monotonically increasing offsets in the range [0, 1].
+func (r *renderer) computeGradientStops(stops []GradientStop, opacity float64) []gradientStop {
+	resolved := make([]gradientStop, len(stops))
+
+	last := 0.0
+	for i, s := range stops {
+		offset := r.computeNumberPercentage(1.0, &s.Offset)
+		switch {
+		case offset < last:
+			offset = last
+		case offset > 1:
+			offset = 1
+		}
+		last = offset
+
+		c := s.Color.Value
+		if c == nil {
+			c = color.Black
+		}
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+
+		a := r.computeNumberPercentage(1.0, s.Opacity) * opacity * float64(nc.A) / 255
+		resolved[i] = gradientStop{
+			offset: offset,
+			color: color.RGBA{
+				R: uint8(float64(nc.R)*a + 0.5),
+				G: uint8(float64(nc.G)*a + 0.5),
+				B: uint8(float64(nc.B)*a + 0.5),
+				A: uint8(255*a + 0.5),
+			},
+		}
+	}
+
+	return resolved
+}
+
+// resolveGradient returns a copy of the given gradient that inherits any unspecified
+// attributes and stops from the gradients it references via href. Elements that are not
+// gradients are returned as-is.
+func (r *renderer) resolveGradient(e Element) Element {
+	switch e := e.(type) {
+	case *LinearGradient:
+		resolved := *e
+		r.inheritGradient(&resolved.Gradient, func(template Element) {
+			if t, ok := template.(*LinearGradient); ok {
+				inheritLength(&resolved.X1, t.X1)
+				inheritLength(&resolved.Y1, t.Y1)
+				inheritLength(&resolved.X2, t.X2)
+				inheritLength(&resolved.Y2, t.Y2)
+			}
+		})
+		return &resolved
+	case *RadialGradient:
+		resolved := *e
+		r.inheritGradient(&resolved.Gradient, func(template Element) {
+			if t, ok := template.(*RadialGradient); ok {
+				inheritLength(&resolved.Cx, t.Cx)
+				inheritLength(&resolved.Cy, t.Cy)
+				inheritLength(&resolved.R, t.R)
+				inheritLength(&resolved.Fx, t.Fx)
+				inheritLength(&resolved.Fy, t.Fy)
+				inheritLength(&resolved.Fr, t.Fr)
+			}
+		})
+		return &resolved
+	default:
+		return e
+	}
+}
+
+// inheritGradient follows the chain of templates referenced by the given gradient's href,
+// filling in any attributes common to all gradients that the gradient does not specify. The
+// inherit function is called with each template in order to inherit the attributes specific
+// to a kind of gradient. References to missing elements or non-gradients end the chain, as do
+// circular references.
+func (r *renderer) inheritGradient(g *Gradient, inherit func(template Element)) {
+	visited := map[Element]bool{}
+	for href := g.Href; href != ""; {
+		template, _ := r.lookup(href)
+		if template == nil || visited[template] {
+			return
+		}
+		visited[template] = true
+
+		var t *Gradient
+		switch template := template.(type) {
+		case *LinearGradient:
+			t = &template.Gradient
+		case *RadialGradient:
+			t = &template.Gradient
+		default:
+			return
+		}
+
+		if g.GradientUnits == "" {
+			g.GradientUnits = t.GradientUnits
+		}
+		if g.GradientTransform == nil {
+			g.GradientTransform = t.GradientTransform
+		}
+		if g.SpreadMethod == "" {
+			g.SpreadMethod = t.SpreadMethod
+		}
+		if len(g.Stops) == 0 {
+			g.Stops = t.Stops
+		}
+		inherit(template)
+
+		href = t.Href
+	}
+}
+
+func inheritLength(dest **LengthPercentage, src *LengthPercentage) {
+	if *dest == nil {
+		*dest = src
+	}
+}
+
+// gradientSpace returns the transform from the given gradient's coordinate system into device
+// space, along with the width and height against which percentages in that coordinate system
+// are resolved. If the gradient uses objectBoundingBox units and the bounding box has no
+// area, gradientSpace returns false, as the gradient cannot be used.
+func (r *renderer) gradientSpace(ctx *gg.Context, g *Gradient, bounds boundingBox) (gg.Matrix, float64, float64, bool) {
+	space := g.GradientTransform.Matrix()
+	if g.GradientUnits == UserSpaceOnUse {
+		return space.Multiply(currentMatrix(ctx)), r.width(), r.height(), true
+	}
+
+	if !bounds.valid || bounds.width() == 0 || bounds.height() == 0 {
+		return gg.Matrix{}, 0, 0, false
+	}
+	return space.Multiply(bounds.matrix()).Multiply(currentMatrix(ctx)), 1, 1, true
+}
+
+// computeGradientCoordinate resolves a gradient attribute. If the attribute is not present,
+// its value is the given fraction of the parent length.
+func (r *renderer) computeGradientCoordinate(parent, fraction float64, lp *LengthPercentage) float64 {
+	if lp == nil {
+		return fraction * parent
+	}
+	return r.computeLengthPercentage(parent, *lp)
+}
+
+// spreadOffset maps a gradient offset into the range [0, 1] according to the given spread
+// method.
+func spreadOffset(t float64, method string) float64 {
+	switch method {
+	case "reflect":
+		t = math.Mod(math.Abs(t), 2)
+		if t > 1 {
+			t = 2 - t
+		}
+		return t
+	case "repeat":
+		return t - math.Floor(t)
+	default:
+		return t
+	}
+}
+
+// gradientColorAt returns the color of a gradient with the given stops at offset t. Offsets
+// outside of the range [0, 1] take the color of the nearest stop.
+func gradientColorAt(stops []gradientStop, t float64) color.Color {
+	if t <= stops[0].offset {
+		return stops[0].color
+	}
+	for i := 1; i < len(stops); i++ {
+		s0, s1 := stops[i-1], stops[i]
+		if t < s1.offset {
+			u := (t - s0.offset) / (s1.offset - s0.offset)
+			lerp := func(a, b uint8) uint8 {
+				return uint8(float64(a) + (float64(b)-float64(a))*u + 0.5)
+			}
+			return color.RGBA{
+				R: lerp(s0.color.R, s1.color.R),
+				G: lerp(s0.color.G, s1.color.G),
+				B: lerp(s0.color.B, s1.color.B),
+				A: lerp(s0.color.A, s1.color.A),
+			}
+		}
+	}
+	return stops[len(stops)-1].color
+}
+
+// linearGradient is a gg.Pattern that paints an SVG linear gradient. The gradient vector
+// is given in gradient space; inverse maps device space into gradient space.
+type linearGradient struct {
+	inverse gg.Matrix
+
+	x1, y1, x2, y2 float64
+
+	spread string
+	stops  []gradientStop
+}
+
+func (g *linearGradient) ColorAt(x, y int) color.Color {
+	px, py := g.inverse.TransformPoint(float64(x)+0.5, float64(y)+0.5)
+
+	dx, dy := g.x2-g.x1, g.y2-g.y1
+	t := ((px-g.x1)*dx + (py-g.y1)*dy) / (dx*dx + dy*dy)
+	return gradientColorAt(g.stops, spreadOffset(t, g.spread))
+}
+
+// radialGradient is a gg.Pattern that paints an SVG radial gradient. The gradient is a
+// two-point conical gradient that interpolates between the focal circle (fx, fy, fr) and
+// the end circle (cx, cy, r). The circles are given in gradient space; inverse maps device
+// space into gradient space.
+type radialGradient struct {
+	inverse gg.Matrix
+
+	fx, fy, fr float64
+	cx, cy, r  float64
+
+	spread string
+	stops  []gradientStop
+}
+
+func (g *radialGradient) ColorAt(x, y int) color.Color {
+	px, py := g.inverse.TransformPoint(float64(x)+0.5, float64(y)+0.5)
+
+	// Find the largest t such that the point lies on the circle centered at
+	// f + t * (c - f) with radius fr + t * (r - fr), where that radius is not negative.
+	// This reduces to solving a*t^2 - 2*b*t + c = 0.
+	cdx, cdy, dr := g.cx-g.fx, g.cy-g.fy, g.r-g.fr
+	pdx, pdy := px-g.fx, py-g.fy
+
+	a := cdx*cdx + cdy*cdy - dr*dr
+	b := pdx*cdx + pdy*cdy + g.fr*dr
+	c := pdx*pdx + pdy*pdy - g.fr*g.fr
+
+	valid := func(t float64) bool {
+		return g.fr+t*dr >= 0
+	}
+
+	var t float64
+	if math.Abs(a) < 1e-9 {
+		if b == 0 {
+			return color.Transparent
+		}
+		t = c / (2 * b)
+		if !valid(t) {
+			return color.Transparent
+		}
+	} else {
+		disc := b*b - a*c
+		if disc < 0 {
+			return color.Transparent
+		}
+		sqrt := math.Sqrt(disc)
+		t1, t2 := (b+sqrt)/a, (b-sqrt)/a
+		if t1 < t2 {
+			t1, t2 = t2, t1
+		}
+		switch {
+		case valid(t1):
+			t = t1
+		case valid(t2):
+			t = t2
+		default:
+			return color.Transparent
+		}
+	}
+
+	return gradientColorAt(g.stops, spreadOffset(t, g.spread))
+}
+
+// resolvePattern returns a copy of the given pattern that inherits any unspecified attributes
+// and children from the patterns it references via href. References to missing elements or
+// non-patterns end the chain, as do circular references.
+func (r *renderer) resolvePattern(e *Pattern) *Pattern {
+	resolved := *e
+
+	visited := map[Element]bool{}
+	for href := e.Href; href != ""; {
+		template, _ := r.lookup(href)
+		t, ok := template.(*Pattern)
+		if !ok || visited[t] {
+			break
+		}
+		visited[t] = true
+
+		inheritLength(&resolved.X, t.X)
+		inheritLength(&resolved.Y, t.Y)
+		inheritLength(&resolved.Width, t.Width)
+		inheritLength(&resolved.Height, t.Height)
+		if resolved.ViewBox == nil {
+			resolved.ViewBox = t.ViewBox
+		}
+		if resolved.PreserveAspectRatio.Align == "" {
+			resolved.PreserveAspectRatio = t.PreserveAspectRatio
+		}
+		if resolved.PatternUnits == "" {
+			resolved.PatternUnits = t.PatternUnits
+		}
+		if resolved.PatternContentUnits == "" {
+			resolved.PatternContentUnits = t.PatternContentUnits
+		}
+		if resolved.PatternTransform == nil {
+			resolved.PatternTransform = t.PatternTransform
+		}
+		if len(resolved.Children) == 0 {
+			resolved.Children = t.Children
+		}
+
+		href = t.Href
+	}
+
+	return &resolved
+}
+
+// computeTilePattern renders a single tile of the given pattern and returns a gg.Pattern that
+// repeats the tile. bounds is the bounding box of the element being painted.
+func (r *renderer) computeTilePattern(ctx *gg.Context, e *Pattern, patternOpacity float64, bounds boundingBox) (gg.Pattern, error) {
+	// A pattern whose content refers to the pattern itself is not rendered.
+	if r.patterns[e] {
+		return gg.NewSolidPattern(color.Transparent), nil
+	}
+
+	original := e
+	e = r.resolvePattern(e)
+
+	// Compute the tile's rectangle in the pattern coordinate system.
+	var x, y, width, height float64
+	if e.PatternUnits == UserSpaceOnUse {
+		x, y = r.computeGradientCoordinate(r.width(), 0, e.X), r.computeGradientCoordinate(r.height(), 0, e.Y)
+		width, height = r.computeGradientCoordinate(r.width(), 0, e.Width), r.computeGradientCoordinate(r.height(), 0, e.Height)
+	} else {
+		if !bounds.valid {
+			return gg.NewSolidPattern(color.Transparent), nil
+		}
+		x = bounds.minX + r.computeGradientCoordinate(1, 0, e.X)*bounds.width()
+		y = bounds.minY + r.computeGradientCoordinate(1, 0, e.Y)*bounds.height()
+		width = r.computeGradientCoordinate(1, 0, e.Width) * bounds.width()
+		height = r.computeGradientCoordinate(1, 0, e.Height) * bounds.height()
+	}
+
+	// A zero-sized tile or view box disables rendering of the pattern.
+	if width <= 0 || height <= 0 || e.ViewBox != nil && (e.ViewBox.Width <= 0 || e.ViewBox.Height <= 0) {
+		return gg.NewSolidPattern(color.Transparent), nil
+	}
+
+	space := e.PatternTransform.Matrix().Multiply(currentMatrix(ctx))
+	inverse, ok := invertMatrix(space)
+	if !ok {
+		return gg.NewSolidPattern(color.Transparent), nil
+	}
+
+	// Render the tile at device resolution. The tile's size in pixels is determined by the
+	// scale of the pattern space along each axis.
+	tileWidth := int(math.Ceil(width * math.Hypot(space.XX, space.YX)))
+	tileHeight := int(math.Ceil(height * math.Hypot(space.XY, space.YY)))
+	if tileWidth < 1 {
+		tileWidth = 1
+	}
+	if tileHeight < 1 {
+		tileHeight = 1
+	}
+	scaleX, scaleY := float64(tileWidth)/width, float64(tileHeight)/height
+
+	tile := gg.NewContext(tileWidth, tileHeight)
+	tile.Scale(scaleX, scaleY)
+	switch {
+	case e.ViewBox != nil:
+		applyViewBox(tile, e.ViewBox, e.PreserveAspectRatio, 0, 0, width, height)
+	case e.PatternContentUnits == ObjectBoundingBox:
+		tile.Scale(bounds.width(), bounds.height())
+	}
+
+	// The pattern's contents inherit properties from the pattern and its ancestors rather than
+	// from the element being painted. Clip masks are specific to the destination context, so they are set
+	// aside while the tile is rendered.
+	stack, clips := r.stack, r.clips
+	r.stack, r.clips = append(r.ancestorStack(original), &element{Element: e, width: width, height: height}), nil
+	if r.patterns == nil {
+		r.patterns = map[*Pattern]bool{}
+	}
+	r.patterns[original] = true
+	defer func() {
+		r.stack, r.clips = stack, clips
+		delete(r.patterns, original)
+	}()
+
+	if err := r.renderCompositingGroup(tile, e.Children); err != nil {
+		return nil, err
+	}
+
+	return &tilePattern{
+		inverse: inverse.Multiply(gg.Translate(-x, -y)).Multiply(gg.Scale(scaleX, scaleY)),
+		tile:    tile.Image().(*image.RGBA),
+		opacity: patternOpacity,
+	}, nil
+}
+
+// tilePattern is a gg.Pattern that repeats a rendered pattern tile. inverse maps device space
+// into the pixel space of the tile.
+type tilePattern struct {
+	inverse gg.Matrix
+	tile    *image.RGBA
+	opacity float64
+}
+
+func (p *tilePattern) ColorAt(x, y int) color.Color {
+	px, py := p.inverse.TransformPoint(float64(x)+0.5, float64(y)+0.5)
+
+	size := p.tile.Bounds().Size()
+	tx, ty := int(math.Floor(px))%size.X, int(math.Floor(py))%size.Y
+	if tx < 0 {
+		tx += size.X
+	}
+	if ty < 0 {
+		ty += size.Y
+	}
+
+	c := p.tile.RGBAAt(tx, ty)
+	if p.opacity < 1 {
+		c = color.RGBA{
+			R: uint8(float64(c.R)*p.opacity + 0.5),
+			G: uint8(float64(c.G)*p.opacity + 0.5),
+			B: uint8(float64(c.B)*p.opacity + 0.5),
+			A: uint8(float64(c.A)*p.opacity + 0.5),
+		}
+	}
+	return c
+}
@@ -0,0 +1,289 @@
+package svg
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// paintedRect is a rect that covers a 40x40 document with the paint server with the ID g.
+const paintedRect = `<rect width="40" height="40" fill="url(#g)"/>`
+
+// redToBlue returns the color at offset t of a gradient from red to blue.
+func redToBlue(t float64) color.NRGBA {
+	return color.NRGBA{R: uint8(255*(1-t) + 0.5), B: uint8(255*t + 0.5), A: 255}
+}
+
+const redToBlueStops = `<stop offset="0" stop-color="red"/><stop offset="1" stop-color="blue"/>`
+
+// centerDistance returns the distance from the center of the given pixel to (cx, cy).
+func centerDistance(x, y int, cx, cy float64) float64 {
+	return math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+}
+
+func TestRadialGradientFocalRadius(t *testing.T) {
+	// Offset 0 lies on the focal circle of radius 10, so the stops are spread over the
+	// distances 10 to 20 from the center.
+	img := renderElements(t, 40, 40, `<radialGradient id="g" gradientUnits="userSpaceOnUse" cx="20" cy="20" r="20" fr="10">`+redToBlueStops+`</radialGradient>`+paintedRect)
+
+	assertColor(t, img, 21, 21, red)
+	assertColor(t, img, 25, 20, red)
+	assertColor(t, img, 35, 20, redToBlue((centerDistance(35, 20, 20, 20)-10)/10))
+	assertColor(t, img, 20, 5, redToBlue((centerDistance(20, 5, 20, 20)-10)/10))
+	assertColor(t, img, 0, 0, blue)
+}
+
+func TestRadialGradientFocalPoint(t *testing.T) {
+	// A focal point inside the end circle. Along the line through the focal point and the
+	// center, the stops are spread over the 10 units to the left of the focal point and the
+	// 30 units to its right.
+	img := renderElements(t, 40, 40, `<radialGradient id="g" gradientUnits="userSpaceOnUse" cx="20" cy="20" r="20" fx="10" fy="20">`+redToBlueStops+`</radialGradient>`+paintedRect)
+
+	assertColor(t, img, 5, 20, redToBlue((10-5.5)/10))
+	assertColor(t, img, 25, 20, redToBlue((25.5-10)/30))
+	assertColor(t, img, 0, 0, blue)
+
+	// A focal point outside of the end circle produces a cone tangent to both circles. The
+	// area outside of the cone is not painted.
+	img = renderElements(t, 40, 40, `<radialGradient id="g" gradientUnits="userSpaceOnUse" cx="20" cy="20" r="10" fx="2" fy="20">`+redToBlueStops+`</radialGradient>`+paintedRect)
+
+	assertColor(t, img, 2, 2, transparent)
+	assertColor(t, img, 0, 20, transparent)
+	assertColor(t, img, 2, 38, transparent)
+
+	// Within the cone, the larger of the two circles that pass through a point determines its
+	// color. The point (6, 20) lies on the circles at t = 1/7 and t = 1/2.
+	c := at(img, 6, 20)
+	assert.InDelta(t, 128, int(c.R), 16)
+	assert.InDelta(t, 128, int(c.B), 16)
+	assertColor(t, img, 20, 20, blue)
+}
+
+func TestRadialGradientSpreadMethod(t *testing.T) {
+	// The pixel at (37, 20) lies at about 1.75 times the gradient's radius from its center.
+	d := centerDistance(37, 20, 20, 20) / 10
+
+	cases := []struct {
+		method   string
+		expected color.NRGBA
+	}{
+		{"pad", blue},
+		{"reflect", redToBlue(2 - d)},
+		{"repeat", redToBlue(d - 1)},
+	}
+	for _, c := range cases {
+		t.Run(c.method, func(t *testing.T) {
+			img := renderElements(t, 40, 40, `<radialGradient id="g" gradientUnits="userSpaceOnUse" cx="20" cy="20" r="10" spreadMethod="`+c.method+`">`+redToBlueStops+`</radialGradient>`+paintedRect)
+
+			assertColor(t, img, 22, 20, redToBlue(centerDistance(22, 20, 20, 20)/10))
+			assertColor(t, img, 37, 20, c.expected)
+		})
+	}
+}
+
+func TestGradientHref(t *testing.T) {
+	t.Run("attributes and stops", func(t *testing.T) {
+		// Stops and attributes are inherited through a chain of templates, and attributes
+		// specified on a gradient take precedence.
+		img := renderElements(t, 40, 40, `
+			<linearGradient id="a" gradientUnits="userSpaceOnUse" x1="0" x2="10" spreadMethod="repeat">`+redToBlueStops+`</linearGradient>
+			<linearGradient id="b" href="#a" x2="20"/>
+			<linearGradient id="g" href="#b"/>`+paintedRect)
+
+		assertColor(t, img, 5, 20, redToBlue(5.5/20))
+		assertColor(t, img, 25, 20, redToBlue(5.5/20))
+	})
+
+	t.Run("own stops", func(t *testing.T) {
+		img := renderElements(t, 40, 40, `
+			<linearGradient id="a">`+redToBlueStops+`</linearGradient>
+			<linearGradient id="g" href="#a"><stop stop-color="lime"/></linearGradient>`+paintedRect)
+
+		assertColor(t, img, 5, 20, color.NRGBA{G: 255, A: 255})
+	})
+
+	t.Run("other kind", func(t *testing.T) {
+		// A linear gradient inherits only the attributes common to all gradients from a
+		// radial gradient.
+		img := renderElements(t, 40, 40, `
+			<radialGradient id="a" gradientUnits="userSpaceOnUse" cx="0" r="5">`+redToBlueStops+`</radialGradient>
+			<linearGradient id="g" href="#a" x2="40"/>`+paintedRect)
+
+		assertColor(t, img, 10, 20, redToBlue(10.5/40))
+		assertColor(t, img, 10, 0, redToBlue(10.5/40))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		// Circular references end the chain of templates.
+		img := renderElements(t, 40, 40, `
+			<linearGradient id="a" href="#g">`+redToBlueStops+`</linearGradient>
+			<linearGradient id="g" href="#a" gradientUnits="userSpaceOnUse" x2="40"/>`+paintedRect)
+		assertColor(t, img, 10, 20, redToBlue(10.5/40))
+
+		img = renderElements(t, 40, 40, `<linearGradient id="g" href="#g">`+redToBlueStops+`</linearGradient>`+paintedRect)
+		assertColor(t, img, 10, 20, redToBlue(10.5/40))
+
+		// A cycle without stops is not painted.
+		img = renderElements(t, 40, 40, `
+			<linearGradient id="a" href="#g"/>
+			<linearGradient id="g" href="#a"/>`+paintedRect)
+		assertColor(t, img, 10, 20, transparent)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		img := renderElements(t, 40, 40, `<linearGradient id="g" href="#missing">`+redToBlueStops+`</linearGradient>`+paintedRect)
+		assertColor(t, img, 10, 20, redToBlue(10.5/40))
+	})
+}
+
+func TestGradientBoundingBoxUnits(t *testing.T) {
+	doc := func(gradient string) image.Image {
+		return renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="40">
+			<defs>`+gradient+`</defs>
+			<rect x="10" y="10" width="20" height="10" fill="url(#g)"/>
+		</svg>`)
+	}
+
+	// Coordinates are fractions of the bounding box.
+	img := doc(`<linearGradient id="g">` + redToBlueStops + `</linearGradient>`)
+	assertColor(t, img, 10, 15, redToBlue(0.5/20))
+	assertColor(t, img, 25, 15, redToBlue(15.5/20))
+
+	// The gradient transform is applied in bounding box units, so rotating the gradient
+	// vector by 90 degrees about the center of the box makes it run from top to bottom.
+	img = doc(`<linearGradient id="g" gradientTransform="rotate(90 0.5 0.5)">` + redToBlueStops + `</linearGradient>`)
+	assertColor(t, img, 11, 11, redToBlue(1.5/10))
+	assertColor(t, img, 28, 11, redToBlue(1.5/10))
+	assertColor(t, img, 20, 18, redToBlue(8.5/10))
+
+	// A bounding box with no width or height cannot be used, so the element is not painted.
+	img = renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="40">
+		<linearGradient id="g">`+redToBlueStops+`</linearGradient>
+		<line x1="5" y1="20" x2="35" y2="20" stroke="url(#g)" stroke-width="4"/>
+	</svg>`)
+	assert.True(t, paintedBounds(img, red).Empty())
+	assertColor(t, img, 20, 20, transparent)
+
+	img = renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="40">
+		<linearGradient id="g" gradientUnits="userSpaceOnUse" x2="40">`+redToBlueStops+`</linearGradient>
+		<line x1="5" y1="20" x2="35" y2="20" stroke="url(#g)" stroke-width="4"/>
+	</svg>`)
+	assertColor(t, img, 20, 20, redToBlue(20.5/40))
+}
+
+func TestPatternUnits(t *testing.T) {
+	// Each pattern has a 10x10 tile with a 5x5 red square in its top-left corner.
+	cases := []struct {
+		name, pattern string
+	}{
+		{"objectBoundingBox", `<pattern id="g" width="0.25" height="0.25"><rect width="5" height="5" fill="red"/></pattern>`},
+		{"userSpaceOnUse", `<pattern id="g" patternUnits="userSpaceOnUse" width="10" height="10"><rect width="5" height="5" fill="red"/></pattern>`},
+		{"patternContentUnits", `<pattern id="g" width="0.25" height="0.25" patternContentUnits="objectBoundingBox"><rect width="0.125" height="0.125" fill="red"/></pattern>`},
+		{"viewBox", `<pattern id="g" patternUnits="userSpaceOnUse" width="10" height="10" viewBox="0 0 100 100"><rect width="50" height="50" fill="red"/></pattern>`},
+		{"viewBox overrides patternContentUnits", `<pattern id="g" patternUnits="userSpaceOnUse" width="10" height="10" viewBox="0 0 100 100" patternContentUnits="objectBoundingBox"><rect width="50" height="50" fill="red"/></pattern>`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			img := renderElements(t, 40, 40, c.pattern+paintedRect)
+
+			assertColor(t, img, 2, 2, red)
+			assertColor(t, img, 32, 22, red)
+			assertColor(t, img, 7, 7, transparent)
+			assertColor(t, img, 2, 7, transparent)
+			assertColor(t, img, 37, 37, transparent)
+		})
+	}
+
+	t.Run("tile origin", func(t *testing.T) {
+		// Tiles are positioned relative to the bounding box in objectBoundingBox units and to
+		// the user space origin otherwise.
+		doc := func(pattern string) image.Image {
+			return renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="40">
+				<defs>`+pattern+`</defs>
+				<rect x="5" y="5" width="20" height="20" fill="url(#g)"/>
+			</svg>`)
+		}
+
+		img := doc(`<pattern id="g" width="0.5" height="0.5"><rect width="5" height="5" fill="red"/></pattern>`)
+		assertColor(t, img, 7, 7, red)
+		assertColor(t, img, 12, 12, transparent)
+
+		img = doc(`<pattern id="g" patternUnits="userSpaceOnUse" width="10" height="10"><rect width="5" height="5" fill="red"/></pattern>`)
+		assertColor(t, img, 7, 7, transparent)
+		assertColor(t, img, 12, 12, red)
+	})
+}
+
+func TestPatternInheritance(t *testing.T) {
+	// The pattern's contents inherit properties from the pattern's ancestors rather than from
+	// the element being painted.
+	img := renderElements(t, 40, 40, `
+		<defs>
+			<g fill="blue">
+				<pattern id="g" patternUnits="userSpaceOnUse" width="10" height="10"><rect width="5" height="5"/></pattern>
+			</g>
+		</defs>
+		<g fill="red">`+paintedRect+`</g>`)
+
+	assertColor(t, img, 2, 2, blue)
+	assertColor(t, img, 7, 7, transparent)
+
+	// Properties on the pattern itself take precedence.
+	img = renderElements(t, 40, 40, `
+		<g fill="blue">
+			<pattern id="g" patternUnits="userSpaceOnUse" width="10" height="10" fill="red"><rect width="5" height="5"/></pattern>
+		</g>`+paintedRect)
+
+	assertColor(t, img, 2, 2, red)
+}
+
+func TestPatternHref(t *testing.T) {
+	// Attributes and children are inherited from templates.
+	img := renderElements(t, 40, 40, `
+		<pattern id="a" patternUnits="userSpaceOnUse" width="10" height="10"><rect width="5" height="5" fill="red"/></pattern>
+		<pattern id="b" href="#a" patternTransform="translate(5 5)"/>
+		<pattern id="g" href="#b"/>`+paintedRect)
+
+	assertColor(t, img, 7, 7, red)
+	assertColor(t, img, 2, 2, transparent)
+
+	// A pattern with its own children does not inherit any.
+	img = renderElements(t, 40, 40, `
+		<pattern id="a" patternUnits="userSpaceOnUse" width="10" height="10"><rect width="5" height="5" fill="red"/></pattern>
+		<pattern id="g" href="#a"><rect x="5" width="5" height="5" fill="blue"/></pattern>`+paintedRect)
+
+	assertColor(t, img, 2, 2, transparent)
+	assertColor(t, img, 7, 2, blue)
+
+	// Circular references end the chain of templates.
+	img = renderElements(t, 40, 40, `
+		<pattern id="a" href="#g" patternUnits="userSpaceOnUse" width="10" height="10"/>
+		<pattern id="g" href="#a"><rect width="5" height="5" fill="red"/></pattern>`+paintedRect)
+
+	assertColor(t, img, 2, 2, red)
+	assertColor(t, img, 7, 7, transparent)
+}
+
+func TestPatternSeams(t *testing.T) {
+	// Tiles whose contents cover them completely leave no gaps between them, whether or not
+	// the tile size is a whole number of pixels.
+	for _, size := range []string{"10", "7.5", "3.3"} {
+		t.Run(size, func(t *testing.T) {
+			img := renderElements(t, 40, 40, `<pattern id="g" patternUnits="userSpaceOnUse" width="`+size+`" height="`+size+`">
+				<rect width="100%" height="100%" fill="blue"/>
+			</pattern>`+paintedRect)
+
+			assert.Equal(t, image.Rect(0, 0, 40, 40), paintedBounds(img, blue))
+			for y := 0; y < 40; y++ {
+				for x := 0; x < 40; x++ {
+					if c := at(img, x, y); c.A != 255 {
+						t.Fatalf("(%v, %v): %v", x, y, c)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,691 @@
+package svg
+
+import (
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/raster"
+	"golang.org/x/image/math/fixed"
+)
+
+// strokeSubpath is a flattened subpath in stroke space. tangents holds the tangents of the
+// subpath at each of its points.
+type strokeSubpath struct {
+	points   []gg.Point
+	tangents []tangent
+	closed   bool
+}
+
+// tangent holds the unit directions and signed curvatures of a subpath where it arrives at and
+// leaves a point. Positive curvatures turn toward the left-hand side of the subpath. A zero
+// direction is that of the adjacent segment of the polyline. Points that lie inside a
+// flattened curve are smooth.
+type tangent struct {
+	in, out   gg.Point
+	kin, kout float64
+	smooth    bool
+}
+
+// strokePath is a pathBuilder that records the subpaths of a path as polylines so that they
+// can be stroked by the renderer. gg's stroker supports neither miter joins nor joins between
+// the ends of closed subpaths, so the renderer strokes paths itself. If path is not nil,
+// segments are also added to it.
+//
+// The polylines are recorded in stroke space, which is user space scaled uniformly by scale.
+// Stroking in a scaled user space rather than in device space keeps the stroke's geometry
+// correct under non-uniform transforms, and the scale keeps the stroker's fixed-point
+// coordinates at least as precise as device space. See strokeScale.
+type strokePath struct {
+	path     pathBuilder
+	scale    float64
+	subpaths []strokeSubpath
+
+	// (x, y) is the current point in user space and (sx, sy) is the start of the current
+	// subpath. started is true if the current subpath may be extended.
+	x, y, sx, sy float64
+	started      bool
+}
+
+func (p *strokePath) start(x, y float64) {
+	p.subpaths = append(p.subpaths, strokeSubpath{points: []gg.Point{p.transform(x, y)}, tangents: []tangent{{}}})
+	p.x, p.y, p.sx, p.sy, p.started = x, y, x, y, true
+}
+
+// strokeScale returns the scale factor of the stroke space for the given user-to-device
+// matrix. This is the largest factor by which the matrix scales a vector, so that the stroke
+// space is at least as precise as device space in every direction.
+func strokeScale(m gg.Matrix) float64 {
+	// The largest singular value of the matrix's linear part.
+	a, b, c, d := m.XX, m.XY, m.YX, m.YY
+	s := a*a + b*b + c*c + d*d
+	det := a*d - b*c
+	return math.Sqrt((s + math.Sqrt(math.Max(0, s*s-4*det*det))) / 2)
+}
+
+// transform transforms a point in user space into stroke space.
+func (p *strokePath) transform(x, y float64) gg.Point {
+	return gg.Point{X: x * p.scale, Y: y * p.scale}
+}
+
+// coincident returns true if the given stroke-space points are too close together for the
+// stroker to compute the direction of the segment between them.
+func coincident(a, b gg.Point) bool {
+	return math.Abs(a.X-b.X)+math.Abs(a.Y-b.Y) < 1.0/32
+}
+
+// add appends a stroke-space point with the given tangent to the current subpath. Points that
+// coincide with the end of the subpath are ignored, though the subpath keeps the tangent with
+// which they were reached.
+func (p *strokePath) add(pt gg.Point, t tangent) {
+	sp := &p.subpaths[len(p.subpaths)-1]
+	last := len(sp.points) - 1
+	if !coincident(pt, sp.points[last]) {
+		sp.points, sp.tangents = append(sp.points, pt), append(sp.tangents, t)
+		return
+	}
+	lt := &sp.tangents[last]
+	lt.in, lt.kin, lt.smooth = t.in, t.kin, lt.smooth && t.smooth
+}
+
+// leave sets the direction and curvature with which the current subpath leaves its last point.
+func (p *strokePath) leave(dir gg.Point, k float64) {
+	sp := &p.subpaths[len(p.subpaths)-1]
+	lt := &sp.tangents[len(sp.tangents)-1]
+	lt.out, lt.kout = dir, k
+}
+
+// tangent returns the stroke-space unit direction and signed curvature of a curve with the
+// given user-space first and second derivatives.
+func (p *strokePath) tangent(dx, dy, ddx, ddy float64) (gg.Point, float64) {
+	v := math.Hypot(dx, dy)
+	if v < 1e-9 {
+		return gg.Point{}, 0
+	}
+	// The y axis grows downwards, so curves that turn toward their left-hand side have a
+	// negative cross product.
+	return gg.Point{X: dx / v, Y: dy / v}, -(dx*ddy - dy*ddx) / (v * v * v) / p.scale
+}
+
+// flatten adds a curve defined over [0, 1] to the current subpath as a sequence of line
+// segments. length is the stroke-space length of the curve's control polygon, and
+// derivatives returns the user-space first and second derivatives of the curve at t.
+func (p *strokePath) flatten(length float64, at func(t float64) (float64, float64), derivatives func(t float64) (float64, float64, float64, float64)) {
+	p.leave(p.tangent(derivatives(0)))
+	n := int(math.Max(1, math.Min(256, math.Ceil(length/2))))
+	for i := 1; i < n; i++ {
+		t := float64(i) / float64(n)
+		dir, k := p.tangent(derivatives(t))
+		p.add(p.transform(at(t)), tangent{in: dir, out: dir, kin: k, kout: k, smooth: true})
+	}
+	dir, k := p.tangent(derivatives(1))
+	p.add(p.transform(at(1)), tangent{in: dir, kin: k})
+}
+
+// polygonLength returns the stroke-space length of the polyline through the given user-space
+// points.
+func (p *strokePath) polygonLength(points ...float64) float64 {
+	length := 0.0
+	prev := p.transform(points[0], points[1])
+	for i := 2; i < len(points); i += 2 {
+		pt := p.transform(points[i], points[i+1])
+		length += math.Hypot(pt.X-prev.X, pt.Y-prev.Y)
+		prev = pt
+	}
+	return length
+}
+
+func (p *strokePath) MoveTo(x, y float64) {
+	if p.path != nil {
+		p.path.MoveTo(x, y)
+	}
+	p.start(x, y)
+}
+
+func (p *strokePath) LineTo(x, y float64) {
+	if p.path != nil {
+		p.path.LineTo(x, y)
+	}
+	if !p.started {
+		p.start(x, y)
+		return
+	}
+	p.leave(gg.Point{}, 0)
+	p.add(p.transform(x, y), tangent{})
+	p.x, p.y = x, y
+}
+
+func (p *strokePath) QuadraticTo(x1, y1, x2, y2 float64) {
+	if p.path != nil {
+		p.path.QuadraticTo(x1, y1, x2, y2)
+	}
+	if !p.started {
+		p.start(p.x, p.y)
+	}
+	x0, y0 := p.x, p.y
+	p.flatten(p.polygonLength(x0, y0, x1, y1, x2, y2), func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*x0 + 2*mt*t*x1 + t*t*x2, mt*mt*y0 + 2*mt*t*y1 + t*t*y2
+	}, func(t float64) (float64, float64, float64, float64) {
+		mt := 1 - t
+		return 2 * (mt*(x1-x0) + t*(x2-x1)), 2 * (mt*(y1-y0) + t*(y2-y1)), 2 * (x2 - 2*x1 + x0), 2 * (y2 - 2*y1 + y0)
+	})
+	p.x, p.y = x2, y2
+}
+
+func (p *strokePath) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	if p.path != nil {
+		p.path.CubicTo(x1, y1, x2, y2, x3, y3)
+	}
+	if !p.started {
+		p.start(p.x, p.y)
+	}
+	x0, y0 := p.x, p.y
+	p.flatten(p.polygonLength(x0, y0, x1, y1, x2, y2, x3, y3), func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*mt*x0 + 3*mt*mt*t*x1 + 3*mt*t*t*x2 + t*t*t*x3, mt*mt*mt*y0 + 3*mt*mt*t*y1 + 3*mt*t*t*y2 + t*t*t*y3
+	}, func(t float64) (float64, float64, float64, float64) {
+		mt := 1 - t
+		dx := 3 * (mt*mt*(x1-x0) + 2*mt*t*(x2-x1) + t*t*(x3-x2))
+		dy := 3 * (mt*mt*(y1-y0) + 2*mt*t*(y2-y1) + t*t*(y3-y2))
+		ddx := 6 * (mt*(x2-2*x1+x0) + t*(x3-2*x2+x1))
+		ddy := 6 * (mt*(y2-2*y1+y0) + t*(y3-2*y2+y1))
+		return dx, dy, ddx, ddy
+	})
+	p.x, p.y = x3, y3
+}
+
+func (p *strokePath) ClosePath() {
+	if p.path != nil {
+		p.path.ClosePath()
+	}
+	if p.started {
+		// The closing segment is implicit, so drop a final point that returns to the start.
+		// Otherwise, the closing segment is a line.
+		sp := &p.subpaths[len(p.subpaths)-1]
+		if n := len(sp.points); n > 2 && coincident(sp.points[0], sp.points[n-1]) {
+			sp.tangents[0].in, sp.tangents[0].kin = sp.tangents[n-1].in, sp.tangents[n-1].kin
+			sp.points, sp.tangents = sp.points[:n-1], sp.tangents[:n-1]
+		} else {
+			sp.tangents[0].in, sp.tangents[0].kin = gg.Point{}, 0
+			sp.tangents[n-1].out, sp.tangents[n-1].kout = gg.Point{}, 0
+		}
+		sp.closed = true
+	}
+
+	// Segments that follow a closepath begin a new subpath at the start of the closed subpath.
+	p.x, p.y, p.started = p.sx, p.sy, false
+}
+
+func (p *strokePath) NewSubPath() {
+	if p.path != nil {
+		p.path.NewSubPath()
+	}
+	p.started = false
+}
+
+// ellipticalArc adds an elliptical arc centered at (x, y) to a path. The arc is approximated
+// using quadratic Bézier curves in the same manner as gg.Context.DrawEllipticalArc, and is
+// connected to the current point with a line.
+func ellipticalArc(path pathBuilder, x, y, rx, ry, angle1, angle2 float64) {
+	const n = 16
+	for i := 0; i < n; i++ {
+		a1 := angle1 + (angle2-angle1)*float64(i)/n
+		a2 := angle1 + (angle2-angle1)*float64(i+1)/n
+		x0, y0 := x+rx*math.Cos(a1), y+ry*math.Sin(a1)
+		x1, y1 := x+rx*math.Cos((a1+a2)/2), y+ry*math.Sin((a1+a2)/2)
+		x2, y2 := x+rx*math.Cos(a2), y+ry*math.Sin(a2)
+		if i == 0 {
+			path.LineTo(x0, y0)
+		}
+		path.QuadraticTo(2*x1-x0/2-x2/2, 2*y1-y0/2-y2/2, x2, y2)
+	}
+}
+
+// ellipse adds a closed ellipse centered at (x, y) to a path. The ellipse begins at (x+rx, y).
+func ellipse(path pathBuilder, x, y, rx, ry float64) {
+	path.MoveTo(x+rx, y)
+	ellipticalArc(path, x, y, rx, ry, 0, 2*math.Pi)
+	path.ClosePath()
+}
+
+func toFixed(p gg.Point) fixed.Point26_6 {
+	return fixed.Point26_6{X: fixed.Int26_6(math.Round(p.X * 64)), Y: fixed.Int26_6(math.Round(p.Y * 64))}
+}
+
+func fromFixed(p fixed.Point26_6) gg.Point {
+	return gg.Point{X: float64(p.X) / 64, Y: float64(p.Y) / 64}
+}
+
+// outerJoin begins a join by joining the inner side of the turn directly. It returns the
+// adder for the outer side of the turn along with the pivot, the half width of the stroke, the
+// normals of the incoming and outgoing segments on the outer side of the turn, and the side of
+// the subpath on which the outer side lies: 1 for its left-hand side and -1 for its right-hand
+// side. The caller adds the shape of the join to the outer side and finishes it at p + u1.
+func outerJoin(lhs, rhs raster.Adder, halfWidth fixed.Int26_6, pivot, n0, n1 fixed.Point26_6) (outer raster.Adder, p, u0, u1 gg.Point, h, side float64) {
+	p, u0, u1, h = fromFixed(pivot), fromFixed(n0), fromFixed(n1), float64(halfWidth)/64
+
+	outer, inner, side := lhs, rhs, 1.0
+	if u0.X*u1.Y-u0.Y*u1.X < 0 {
+		outer, inner, side = rhs, lhs, -1
+		u0, u1 = gg.Point{X: -u0.X, Y: -u0.Y}, gg.Point{X: -u1.X, Y: -u1.Y}
+	}
+	inner.Add1(toFixed(gg.Point{X: p.X - u1.X, Y: p.Y - u1.Y}))
+	return outer, p, u0, u1, h, side
+}
+
+// addMiter adds a miter join to the outer side of a turn. If the ratio of the join's miter
+// length to the stroke width exceeds limit, the join is clipped at limit if clip is true and
+// beveled otherwise.
+func addMiter(outer raster.Adder, p, u0, u1 gg.Point, h, limit float64, clip bool) {
+	// s bisects the angle between the two normals. The distance from the pivot to the tip of
+	// the miter is h / cos(θ/2), where θ is the angle between the normals and
+	// cos(θ/2) = |s| / 2h.
+	s := gg.Point{X: u0.X + u1.X, Y: u0.Y + u1.Y}
+	sl := math.Hypot(s.X, s.Y)
+	if sl <= 1e-9 {
+		return
+	}
+	ratio := 2 * h / sl
+	tip := gg.Point{X: p.X + s.X*ratio*h/sl, Y: p.Y + s.Y*ratio*h/sl}
+	switch {
+	case ratio <= limit:
+		outer.Add1(toFixed(tip))
+	case clip:
+		// Clip the miter with a line perpendicular to the bisector at a distance of limit * h
+		// from the pivot.
+		c := sl / 2
+		t := (limit*h - c) / (ratio*h - c)
+		outer.Add1(toFixed(gg.Point{X: p.X + u0.X + (tip.X-p.X-u0.X)*t, Y: p.Y + u0.Y + (tip.Y-p.Y-u0.Y)*t}))
+		outer.Add1(toFixed(gg.Point{X: p.X + u1.X + (tip.X-p.X-u1.X)*t, Y: p.Y + u1.Y + (tip.Y-p.Y-u1.Y)*t}))
+	}
+}
+
+// miterJoiner returns a joiner that adds miter joins to a stroked path. If the ratio of a
+// join's miter length to the stroke width exceeds limit, the join is clipped at limit if clip
+// is true and beveled otherwise.
+func miterJoiner(limit float64, clip bool) raster.Joiner {
+	return raster.JoinerFunc(func(lhs, rhs raster.Adder, halfWidth fixed.Int26_6, pivot, n0, n1 fixed.Point26_6) {
+		outer, p, u0, u1, h, _ := outerJoin(lhs, rhs, halfWidth, pivot, n0, n1)
+		addMiter(outer, p, u0, u1, h, limit, clip)
+		outer.Add1(toFixed(gg.Point{X: p.X + u1.X, Y: p.Y + u1.Y}))
+	})
+}
+
+// arcsJoiner returns a joiner that adds arcs joins to the given stroked subpath. An arcs join
+// extends the outer edges of the stroke as circular arcs with the curvature of the edges at
+// the join, or as lines if the edges are straight, until they meet. If the extended edges do
+// not meet or the ratio of the distance from the pivot to their intersection to the stroke
+// width exceeds limit, the join falls back to a miter-clip join.
+//
+// The stroker joins the vertices of a subpath in order, so the joiner finds the tangents of
+// the subpath at each join by counting joins.
+func arcsJoiner(limit float64, sp strokeSubpath) raster.Joiner {
+	i := 0
+	return raster.JoinerFunc(func(lhs, rhs raster.Adder, halfWidth fixed.Int26_6, pivot, n0, n1 fixed.Point26_6) {
+		i++
+		t := sp.tangents[i%len(sp.tangents)]
+
+		outer, p, u0, u1, h, side := outerJoin(lhs, rhs, halfWidth, pivot, n0, n1)
+		if t.smooth || !addArcs(outer, p, u0, u1, h, side, t, limit) {
+			addMiter(outer, p, u0, u1, h, limit, true)
+		}
+		outer.Add1(toFixed(gg.Point{X: p.X + u1.X, Y: p.Y + u1.Y}))
+	})
+}
+
+// joinEdge is the extension of an outer edge of a stroke beyond a join. The edge leaves start
+// in the direction dir, and turns toward the left-hand side of dir with curvature k.
+type joinEdge struct {
+	start, dir gg.Point
+	k          float64
+}
+
+// normal returns the unit normal on the left-hand side of the edge's direction. The y axis
+// grows downwards, so this is the direction rotated counter-clockwise on the screen.
+func (e joinEdge) normal() gg.Point {
+	return gg.Point{X: e.dir.Y, Y: -e.dir.X}
+}
+
+// straight returns true if the edge is a line.
+func (e joinEdge) straight() bool {
+	return math.Abs(e.k) < 1e-9
+}
+
+// center returns the center of a curved edge.
+func (e joinEdge) center() gg.Point {
+	n := e.normal()
+	return gg.Point{X: e.start.X + n.X/e.k, Y: e.start.Y + n.Y/e.k}
+}
+
+// at returns the point at distance l along the edge.
+func (e joinEdge) at(l float64) gg.Point {
+	n := e.normal()
+	if e.straight() {
+		return gg.Point{X: e.start.X + e.dir.X*l, Y: e.start.Y + e.dir.Y*l}
+	}
+	s, c := math.Sin(e.k*l)/e.k, (1-math.Cos(e.k*l))/e.k
+	return gg.Point{X: e.start.X + e.dir.X*s + n.X*c, Y: e.start.Y + e.dir.Y*s + n.Y*c}
+}
+
+// distance returns the distance along the edge to a point on the edge.
+func (e joinEdge) distance(pt gg.Point) float64 {
+	n := e.normal()
+	u := (pt.X-e.start.X)*e.dir.X + (pt.Y-e.start.Y)*e.dir.Y
+	if e.straight() {
+		return u
+	}
+	v := (pt.X-e.start.X)*n.X + (pt.Y-e.start.Y)*n.Y
+	l := math.Atan2(e.k*u, 1-e.k*v) / e.k
+	if l < 0 {
+		l += 2 * math.Pi / math.Abs(e.k)
+	}
+	return l
+}
+
+// intersectEdges returns the points at which two edges intersect.
+func intersectEdges(a, b joinEdge) []gg.Point {
+	switch {
+	case a.straight() && b.straight():
+		d := a.dir.X*b.dir.Y - a.dir.Y*b.dir.X
+		if math.Abs(d) < 1e-9 {
+			return nil
+		}
+		t := ((b.start.X-a.start.X)*b.dir.Y - (b.start.Y-a.start.Y)*b.dir.X) / d
+		return []gg.Point{a.at(t)}
+	case a.straight():
+		return intersectEdges(b, a)
+	case b.straight():
+		// Intersect the line with the circle by projecting the circle's center onto the line.
+		c, r := a.center(), 1/math.Abs(a.k)
+		t := (c.X-b.start.X)*b.dir.X + (c.Y-b.start.Y)*b.dir.Y
+		foot := b.at(t)
+		d := math.Hypot(c.X-foot.X, c.Y-foot.Y)
+		if d > r {
+			return nil
+		}
+		w := math.Sqrt(r*r - d*d)
+		return []gg.Point{b.at(t - w), b.at(t + w)}
+	default:
+		c0, r0 := a.center(), 1/math.Abs(a.k)
+		c1, r1 := b.center(), 1/math.Abs(b.k)
+		dx, dy := c1.X-c0.X, c1.Y-c0.Y
+		d := math.Hypot(dx, dy)
+		if d < 1e-9 || d > r0+r1 || d < math.Abs(r0-r1) {
+			return nil
+		}
+		m := (d*d + r0*r0 - r1*r1) / (2 * d)
+		w := math.Sqrt(math.Max(0, r0*r0-m*m))
+		mx, my := c0.X+dx*m/d, c0.Y+dy*m/d
+		return []gg.Point{{X: mx - dy*w/d, Y: my + dx*w/d}, {X: mx + dy*w/d, Y: my - dx*w/d}}
+	}
+}
+
+// addArcs adds an arcs join to the outer side of a turn with the given tangent. It returns
+// false if the join falls back to another join.
+func addArcs(outer raster.Adder, p, u0, u1 gg.Point, h, side float64, t tangent, limit float64) bool {
+	// The outer edges are offset from the path by side * h along its left-hand normals. An
+	// edge offset by s from a path with curvature k shares its center of curvature, so its
+	// curvature is k / (1 - s*k). If the offset reaches the center, the edge degenerates.
+	s := side * h
+	if 1-s*t.kin <= 0 || 1-s*t.kout <= 0 {
+		return false
+	}
+
+	// The edges are extended from the tangents of the path rather than from the segments of
+	// the polyline, which only approximate the directions of curves. The incoming edge is
+	// extended forwards from the end of the incoming segment's outer edge, and the outgoing
+	// edge backwards from the start of the outgoing segment's outer edge. Reversing the
+	// outgoing edge negates its curvature.
+	d0, d1 := t.in, t.out
+	if d0 == (gg.Point{}) {
+		d0 = gg.Point{X: -side * u0.Y / h, Y: side * u0.X / h}
+	}
+	if d1 == (gg.Point{}) {
+		d1 = gg.Point{X: -side * u1.Y / h, Y: side * u1.X / h}
+	}
+	e0 := joinEdge{
+		start: gg.Point{X: p.X + s*d0.Y, Y: p.Y - s*d0.X},
+		dir:   d0,
+		k:     t.kin / (1 - s*t.kin),
+	}
+	e1 := joinEdge{
+		start: gg.Point{X: p.X + s*d1.Y, Y: p.Y - s*d1.X},
+		dir:   gg.Point{X: -d1.X, Y: -d1.Y},
+		k:     -t.kout / (1 - s*t.kout),
+	}
+
+	// The join ends at the intersection that is nearest along the extended edges.
+	var x gg.Point
+	l0, l1, found := 0.0, 0.0, false
+	for _, pt := range intersectEdges(e0, e1) {
+		d0, d1 := e0.distance(pt), e1.distance(pt)
+		if d0 < -1e-6 || d1 < -1e-6 {
+			continue
+		}
+		if !found || d0+d1 < l0+l1 {
+			x, l0, l1, found = pt, math.Max(0, d0), math.Max(0, d1), true
+		}
+	}
+	if !found || math.Hypot(x.X-p.X, x.Y-p.Y) > limit*h {
+		return false
+	}
+
+	// Approximate the arcs with line segments.
+	segments := func(e joinEdge, l float64) int {
+		return int(math.Max(1, math.Ceil(math.Abs(e.k)*l*16/math.Pi)))
+	}
+	outer.Add1(toFixed(e0.start))
+	n := segments(e0, l0)
+	for i := 1; i < n; i++ {
+		outer.Add1(toFixed(e0.at(l0 * float64(i) / float64(n))))
+	}
+	outer.Add1(toFixed(x))
+	n = segments(e1, l1)
+	for i := n - 1; i > 0; i-- {
+		outer.Add1(toFixed(e1.at(l1 * float64(i) / float64(n))))
+	}
+	outer.Add1(toFixed(e1.start))
+	return true
+}
+
+// dashPolyline splits a polyline into dashes using the given stroke-space dash array and
+// offset. The dash array must have an even number of elements and a positive sum.
+func dashPolyline(sp strokeSubpath, dashes []float64, offset float64) []strokeSubpath {
+	points, tangents := sp.points, sp.tangents
+	if sp.closed {
+		points = append(points[:len(points):len(points)], points[0])
+		tangents = append(tangents[:len(tangents):len(tangents)], tangents[0])
+	}
+
+	// Find the dash that contains the start of the path.
+	i, remaining := 0, dashes[0]
+	for offset > 0 {
+		if offset < remaining {
+			remaining -= offset
+			break
+		}
+		offset -= remaining
+		i = (i + 1) % len(dashes)
+		remaining = dashes[i]
+	}
+
+	var result []strokeSubpath
+	var current *strokeSubpath
+	if i%2 == 0 {
+		result = append(result, strokeSubpath{points: []gg.Point{points[0]}, tangents: []tangent{tangents[0]}})
+		current = &result[len(result)-1]
+	}
+	for j := 1; j < len(points); j++ {
+		a, b := points[j-1], points[j]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		pos := 0.0
+		for length-pos > remaining {
+			pos += remaining
+			pt := gg.Point{X: a.X + (b.X-a.X)*pos/length, Y: a.Y + (b.Y-a.Y)*pos/length}
+			if current != nil {
+				// Zero-length dashes are extended slightly so that their caps are oriented
+				// along the path.
+				if len(current.points) == 1 && coincident(current.points[0], pt) {
+					pt = gg.Point{X: current.points[0].X + (b.X-a.X)/length/16, Y: current.points[0].Y + (b.Y-a.Y)/length/16}
+				}
+				current.points, current.tangents = append(current.points, pt), append(current.tangents, tangent{})
+				current = nil
+			} else {
+				result = append(result, strokeSubpath{points: []gg.Point{pt}, tangents: []tangent{{}}})
+				current = &result[len(result)-1]
+			}
+			i = (i + 1) % len(dashes)
+			remaining = dashes[i]
+		}
+		remaining -= length - pos
+		if current != nil {
+			current.points, current.tangents = append(current.points, b), append(current.tangents, tangents[j])
+		}
+	}
+	return result
+}
+
+// stroke strokes the given path using the stroke properties of the element at the top of the
+// stack. bounds is the bounding box of the element, length is the length of its geometry in
+// user space, and pathLength is the value of its pathLength attribute.
+func (r *renderer) stroke(ctx *gg.Context, path *strokePath, bounds boundingBox, pathLength, length float64) error {
+	// The contents of a clipPath are never stroked.
+	if r.clipping {
+		return nil
+	}
+
+	p := r.getStroke()
+	if p == nil || p.URL == "" && p.Context == "" && isTransparent(p.Color) {
+		return nil
+	}
+	strokeOpacity := r.computeNumberPercentage(1.0, r.getStrokeOpacity())
+	paint, err := r.computePaint(ctx, p, strokeOpacity, bounds)
+	if err != nil {
+		return err
+	}
+
+	// The stroke is computed in stroke space, so scale its dimensions accordingly.
+	scale := path.scale
+
+	strokeWidth := 1.0
+	if sw := r.getStrokeWidth(); sw != nil {
+		strokeWidth = r.computeLengthPercentage(r.diag(), *sw)
+	}
+	width := strokeWidth * scale
+	if width <= 0 {
+		return nil
+	}
+
+	linecap := r.getStrokeLinecap()
+
+	var capper raster.Capper
+	switch linecap {
+	case "round":
+		capper = raster.RoundCapper
+	case "square":
+		capper = raster.SquareCapper
+	default:
+		capper = raster.ButtCapper
+	}
+
+	miterLimit := 4.0
+	if ml := r.getStrokeMiterlimit(); ml != nil && *ml >= 1 {
+		miterLimit = *ml
+	}
+
+	// Arcs joins depend on the tangents of each subpath, so their joiners are created per
+	// subpath.
+	linejoin := r.getStrokeLinejoin()
+
+	var joiner raster.Joiner
+	switch linejoin {
+	case "round":
+		joiner = raster.RoundJoiner
+	case "bevel":
+		joiner = raster.BevelJoiner
+	case "miter-clip":
+		joiner = miterJoiner(miterLimit, true)
+	default:
+		joiner = miterJoiner(miterLimit, false)
+	}
+
+	subpaths := path.subpaths
+	if dashes, offset := r.computeDashes(pathLength, length); len(dashes) != 0 {
+		for i := range dashes {
+			dashes[i] *= scale
+		}
+		var dashed []strokeSubpath
+		for _, sp := range subpaths {
+			dashed = append(dashed, dashPolyline(sp, dashes, offset*scale)...)
+		}
+		subpaths = dashed
+	}
+
+	var outline raster.Path
+	for _, sp := range subpaths {
+		// Zero-length subpaths are rendered as caps oriented along the x axis, if any.
+		if len(sp.points) == 1 {
+			if linecap != "round" && linecap != "square" {
+				continue
+			}
+			pt := sp.points[0]
+			sp = strokeSubpath{points: []gg.Point{pt, {X: pt.X + 1.0/16, Y: pt.Y}}, tangents: make([]tangent, 2)}
+		}
+
+		joiner := joiner
+		if linejoin == "arcs" {
+			joiner = arcsJoiner(miterLimit, sp)
+		}
+
+		var q raster.Path
+		q.Start(toFixed(sp.points[0]))
+		for _, pt := range sp.points[1:] {
+			q.Add1(toFixed(pt))
+		}
+
+		// Closed subpaths are stroked past their start to their second point so that the
+		// stroker joins their first and last segments. The overlapping portion of the stroke
+		// is covered twice, which has no effect on the nonzero fill of the outline.
+		if sp.closed {
+			q.Add1(toFixed(sp.points[0]))
+			q.Add1(toFixed(sp.points[1]))
+			raster.Stroke(&outline, q, fixed.Int26_6(math.Round(width*64)), raster.ButtCapper, joiner)
+		} else {
+			raster.Stroke(&outline, q, fixed.Int26_6(math.Round(width*64)), capper, joiner)
+		}
+	}
+
+	// Fill the outline in user space.
+	ctx.Push()
+	defer ctx.Pop()
+
+	ctx.Scale(1/scale, 1/scale)
+	ctx.ClearPath()
+	for i := 0; i < len(outline); {
+		switch outline[i] {
+		case 0:
+			pt := fromFixed(fixed.Point26_6{X: outline[i+1], Y: outline[i+2]})
+			ctx.MoveTo(pt.X, pt.Y)
+			i += 4
+		case 1:
+			pt := fromFixed(fixed.Point26_6{X: outline[i+1], Y: outline[i+2]})
+			ctx.LineTo(pt.X, pt.Y)
+			i += 4
+		case 2:
+			c := fromFixed(fixed.Point26_6{X: outline[i+1], Y: outline[i+2]})
+			pt := fromFixed(fixed.Point26_6{X: outline[i+3], Y: outline[i+4]})
+			ctx.QuadraticTo(c.X, c.Y, pt.X, pt.Y)
+			i += 6
+		case 3:
+			c1 := fromFixed(fixed.Point26_6{X: outline[i+1], Y: outline[i+2]})
+			c2 := fromFixed(fixed.Point26_6{X: outline[i+3], Y: outline[i+4]})
+			pt := fromFixed(fixed.Point26_6{X: outline[i+5], Y: outline[i+6]})
+			ctx.CubicTo(c1.X, c1.Y, c2.X, c2.Y, pt.X, pt.Y)
+			i += 8
+		}
+	}
+	ctx.SetFillStyle(paint)
+	ctx.SetFillRuleWinding()
+	ctx.Fill()
+	return nil
+}
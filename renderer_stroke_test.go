@@ -0,0 +1,167 @@
+package svg
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// spike is a path whose segments meet at (40, 20.5) at an angle of about 28 degrees, so its
+// miter ratio is about 4.13. With a stroke width of 4, the tip of the miter lies at about
+// (48.27, 20.5). The spike is centered on the pixels in row 20.
+const spike = "M 0 30.5 L 40 20.5 L 0 10.5"
+
+func TestStrokeMiterLimit(t *testing.T) {
+	// The default miter limit of 4 is exceeded, so the join is beveled.
+	img := renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4"/>`)
+	assert.Equal(t, uint8(0), at(img, 43, 20).A)
+	assert.Equal(t, uint8(0), at(img, 46, 20).A)
+
+	img = renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="miter" stroke-miterlimit="5"/>`)
+	assert.Equal(t, uint8(255), at(img, 43, 20).A)
+	assert.Greater(t, at(img, 46, 20).A, uint8(128))
+	assert.Equal(t, uint8(0), at(img, 49, 20).A)
+
+	img = renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="bevel" stroke-miterlimit="5"/>`)
+	assert.Equal(t, uint8(0), at(img, 43, 20).A)
+}
+
+func TestStrokeMiterClip(t *testing.T) {
+	// The miter is clipped at half of miterlimit times the stroke width from the vertex, i.e.
+	// at x = 44.
+	img := renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="miter-clip" stroke-miterlimit="2"/>`)
+	assert.Equal(t, uint8(255), at(img, 42, 20).A)
+	assert.Greater(t, at(img, 43, 20).A, uint8(128))
+	assert.Equal(t, uint8(0), at(img, 45, 20).A)
+
+	// The clipped edge is perpendicular to the bisector of the join.
+	for y := 19; y <= 21; y++ {
+		assert.Equal(t, uint8(0), at(img, 44, y).A, "%v", y)
+	}
+
+	// Joins that do not exceed the limit are not clipped.
+	img = renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="miter-clip" stroke-miterlimit="5"/>`)
+	assert.Greater(t, at(img, 46, 20).A, uint8(128))
+}
+
+// lens is a path whose arcs of radius 20 meet at (40, 20.5) like the segments of a spike,
+// but with their centers at (56, 8.5) and (56, 32.5) outside the join, so that its outer
+// edges curve away from each other. Its miter ratio is 1.25: with a stroke width of 14, the
+// tip of the miter lies at (48.75, 20.5).
+const lens = "M 36 8.5 A 20 20 0 0 0 40 20.5 A 20 20 0 0 0 36 32.5"
+
+func TestStrokeArcs(t *testing.T) {
+	// Straight segments meet at the same point as a miter.
+	arcs := renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="arcs" stroke-miterlimit="5"/>`)
+	miter := renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="miter" stroke-miterlimit="5"/>`)
+	for x := 40; x < 52; x++ {
+		assert.InDelta(t, at(miter, x, 20).A, at(arcs, x, 20).A, 8, "%v", x)
+	}
+
+	// The outer edges of the lens have a radius of 13, so they meet on the axis of the join
+	// 5 units before the centers of their arcs, at (51, 20.5).
+	img := renderElements(t, 60, 40, `<path d="`+lens+`" fill="none" stroke="red" stroke-width="14" stroke-linejoin="arcs"/>`)
+	assert.Equal(t, uint8(255), at(img, 49, 20).A)
+	assert.Greater(t, at(img, 50, 20).A, uint8(64))
+	assert.Equal(t, uint8(0), at(img, 51, 20).A)
+
+	img = renderElements(t, 60, 40, `<path d="`+lens+`" fill="none" stroke="red" stroke-width="14" stroke-linejoin="miter"/>`)
+	assert.Less(t, at(img, 48, 20).A, uint8(128))
+	assert.Equal(t, uint8(0), at(img, 49, 20).A)
+
+	// The first and last segments of a closed subpath are joined with arcs as well.
+	img = renderElements(t, 60, 40, `<path d="M 10 10 H 30 V 30 H 10 Z" fill="none" stroke="red" stroke-width="4" stroke-linejoin="arcs"/>`)
+	assert.Equal(t, uint8(255), at(img, 8, 8).A)
+	assert.Equal(t, uint8(255), at(img, 31, 31).A)
+}
+
+func TestStrokeArcsMiterLimit(t *testing.T) {
+	// Joins whose arcs meet beyond the miter limit fall back to miter-clip joins.
+	arcs := renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="arcs" stroke-miterlimit="2"/>`)
+	clip := renderElements(t, 60, 40, `<path d="`+spike+`" fill="none" stroke="red" stroke-width="4" stroke-linejoin="miter-clip" stroke-miterlimit="2"/>`)
+	for x := 40; x < 52; x++ {
+		assert.InDelta(t, at(clip, x, 20).A, at(arcs, x, 20).A, 8, "%v", x)
+	}
+
+	// The arcs of the lens meet 11 units from the join, beyond a limit of 1.5 * 7, but its
+	// miter does not exceed the limit.
+	arcs = renderElements(t, 60, 40, `<path d="`+lens+`" fill="none" stroke="red" stroke-width="14" stroke-linejoin="arcs" stroke-miterlimit="1.5"/>`)
+	miter := renderElements(t, 60, 40, `<path d="`+lens+`" fill="none" stroke="red" stroke-width="14" stroke-linejoin="miter" stroke-miterlimit="1.5"/>`)
+	for x := 40; x < 56; x++ {
+		assert.InDelta(t, at(miter, x, 20).A, at(arcs, x, 20).A, 8, "%v", x)
+	}
+}
+
+func TestStrokeClosedSubpath(t *testing.T) {
+	// The first and last segments of a closed subpath are joined, so the corner at the start
+	// of the subpath is mitered.
+	img := renderElements(t, 60, 40, `<path d="M 10 10 H 30 V 30 H 10 Z" fill="none" stroke="red" stroke-width="4"/>`)
+	assert.Equal(t, uint8(255), at(img, 8, 8).A)
+	assert.Equal(t, uint8(255), at(img, 31, 31).A)
+
+	img = renderElements(t, 60, 40, `<path d="M 10 10 H 30 V 30 H 10 Z" fill="none" stroke="red" stroke-width="4" stroke-linejoin="round"/>`)
+	assert.Less(t, at(img, 8, 8).A, uint8(128))
+
+	// An open subpath that returns to its start is capped rather than joined.
+	img = renderElements(t, 60, 40, `<path d="M 10 10 H 30 V 30 H 10 V 10" fill="none" stroke="red" stroke-width="4"/>`)
+	assert.Equal(t, uint8(0), at(img, 8, 8).A)
+	assert.Equal(t, uint8(255), at(img, 31, 31).A)
+
+	// Closed subpaths are never capped: a square cap at the start would cover (8, 8).
+	img = renderElements(t, 60, 40, `<path d="M 10 10 H 30 V 30 H 10 Z" fill="none" stroke="red" stroke-width="4" stroke-linecap="square" stroke-linejoin="bevel"/>`)
+	assert.Equal(t, uint8(0), at(img, 8, 8).A)
+}
+
+func TestStrokeZeroLengthSubpath(t *testing.T) {
+	for _, d := range []string{"M 10 10 Z", "M 10 10 L 10 10"} {
+		t.Run(d, func(t *testing.T) {
+			img := renderElements(t, 60, 40, `<path d="`+d+`" fill="none" stroke="red" stroke-width="6"/>`)
+			for y := 5; y < 15; y++ {
+				for x := 5; x < 15; x++ {
+					assert.Equal(t, uint8(0), at(img, x, y).A, "(%v, %v)", x, y)
+				}
+			}
+
+			// Square caps cover a square centered on the point.
+			img = renderElements(t, 60, 40, `<path d="`+d+`" fill="none" stroke="red" stroke-width="6" stroke-linecap="square"/>`)
+			assert.Equal(t, uint8(255), at(img, 10, 10).A)
+			assert.Greater(t, at(img, 7, 7).A, uint8(192))
+			assert.Greater(t, at(img, 12, 12).A, uint8(192))
+			assert.Equal(t, uint8(0), at(img, 14, 10).A)
+
+			// Round caps cover a circle centered on the point.
+			img = renderElements(t, 60, 40, `<path d="`+d+`" fill="none" stroke="red" stroke-width="6" stroke-linecap="round"/>`)
+			assert.Equal(t, uint8(255), at(img, 10, 10).A)
+			assert.Greater(t, at(img, 7, 10).A, uint8(192))
+			assert.Less(t, at(img, 12, 12).A, uint8(128))
+			assert.Less(t, at(img, 7, 7).A, uint8(128))
+		})
+	}
+}
+
+func TestStrokeNonUniformScale(t *testing.T) {
+	// The stroke is offset in user space, so its width is scaled along with the path.
+	img := renderElements(t, 60, 40, `<g transform="scale(1 10)">
+		<path d="M 10 2 H 50" fill="none" stroke="red" stroke-width="2"/>
+	</g>`)
+	assert.Equal(t, image.Rect(10, 10, 50, 30), paintedBounds(img, red))
+
+	img = renderElements(t, 60, 40, `<g transform="scale(1 10)">
+		<path d="M 30 0.5 V 3.5" fill="none" stroke="red" stroke-width="2"/>
+	</g>`)
+	assert.Equal(t, image.Rect(29, 5, 31, 35), paintedBounds(img, red))
+
+	// So is a stroke in a view box that is stretched to fit the viewport.
+	img = renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="60" height="40" viewBox="0 0 60 4" preserveAspectRatio="none">
+		<path d="M 10 2 H 50" fill="none" stroke="red" stroke-width="2"/>
+	</svg>`)
+	assert.Equal(t, image.Rect(10, 10, 50, 30), paintedBounds(img, red))
+
+	// Joins and caps are computed in user space as well. The square caps extend the line by
+	// one unit at each end, which is 10 pixels vertically.
+	img = renderElements(t, 60, 40, `<g transform="scale(1 10)">
+		<path d="M 30 1 V 3" fill="none" stroke="red" stroke-width="2" stroke-linecap="square"/>
+	</g>`)
+	assert.Equal(t, image.Rect(29, 0, 31, 40), paintedBounds(img, red))
+}
@@ -32,8 +32,8 @@ func (r *renderer) getBaselineShift() *LengthPercentageIdent {
 	return v
 }
 
-func (r *renderer) getClipPath() *ClipPath {
-	var v *ClipPath
+func (r *renderer) getClipPath() *ClipSource {
+	var v *ClipSource
 	r.getAttr(func(e Element) bool {
 		if i := e.attrs().ClipPath; i != nil {
 			v = i
@@ -380,8 +380,8 @@ func (r *renderer) getMarkerStart() *URLIdent {
 	return v
 }
 
-func (r *renderer) getMask() *Mask {
-	var v *Mask
+func (r *renderer) getMask() *URLIdent {
+	var v *URLIdent
 	r.getAttr(func(e Element) bool {
 		if i := e.attrs().Mask; i != nil {
 			v = i
@@ -392,8 +392,20 @@ func (r *renderer) getMask() *Mask {
 	return v
 }
 
-func (r *renderer) getOpacity() *NumberIdent {
-	var v *NumberIdent
+func (r *renderer) getMaskType() Ident {
+	var v Ident
+	r.getAttr(func(e Element) bool {
+		if i := e.attrs().MaskType; i != "" {
+			v = i
+			return true
+		}
+		return false
+	})
+	return v
+}
+
+func (r *renderer) getOpacity() *NumberPercentage {
+	var v *NumberPercentage
 	r.getAttr(func(e Element) bool {
 		if i := e.attrs().Opacity; i != nil {
 			v = i
@@ -596,18 +608,6 @@ func (r *renderer) getTextRendering() Ident {
 	return v
 }
 
-func (r *renderer) getTransform() []Transform {
-	var v []Transform
-	r.getAttr(func(e Element) bool {
-		if i := e.attrs().Transform; i != nil {
-			v = i
-			return true
-		}
-		return false
-	})
-	return v
-}
-
 func (r *renderer) getUnicodeBidi() Ident {
 	var v Ident
 	r.getAttr(func(e Element) bool {
@@ -656,6 +656,18 @@ func (r *renderer) getWhiteSpace() Ident {
 	return v
 }
 
+func (r *renderer) getXMLSpace() string {
+	var v string
+	r.getAttr(func(e Element) bool {
+		if i := e.attrs().XMLSpace; i != "" {
+			v = i
+			return true
+		}
+		return false
+	})
+	return v
+}
+
 func (r *renderer) getWordSpacing() *LengthIdent {
 	var v *LengthIdent
 	r.getAttr(func(e Element) bool {
@@ -0,0 +1,521 @@
+package svg
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPath is a pathBuilder that records the segments added to it.
+type recordingPath struct {
+	segments []string
+
+	// points holds the current point followed by points sampled along each curve.
+	points [][2]float64
+}
+
+func (p *recordingPath) record(op string, args ...float64) {
+	s := make([]string, len(args))
+	for i, a := range args {
+		// Adding zero normalizes negative zero.
+		s[i] = fmt.Sprintf("%g", math.Round(a*1000)/1000+0)
+	}
+	p.segments = append(p.segments, op+" "+strings.Join(s, " "))
+}
+
+func (p *recordingPath) current() (float64, float64) {
+	c := p.points[len(p.points)-1]
+	return c[0], c[1]
+}
+
+func (p *recordingPath) MoveTo(x, y float64) {
+	p.record("M", x, y)
+	p.points = append(p.points, [2]float64{x, y})
+}
+
+func (p *recordingPath) LineTo(x, y float64) {
+	p.record("L", x, y)
+	p.points = append(p.points, [2]float64{x, y})
+}
+
+func (p *recordingPath) QuadraticTo(x1, y1, x2, y2 float64) {
+	p.record("Q", x1, y1, x2, y2)
+	p.points = append(p.points, [2]float64{x2, y2})
+}
+
+func (p *recordingPath) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	p.record("C", x1, y1, x2, y2, x3, y3)
+
+	x0, y0 := p.current()
+	for i := 1; i <= 16; i++ {
+		t := float64(i) / 16
+		mt := 1 - t
+		p.points = append(p.points, [2]float64{
+			mt*mt*mt*x0 + 3*mt*mt*t*x1 + 3*mt*t*t*x2 + t*t*t*x3,
+			mt*mt*mt*y0 + 3*mt*mt*t*y1 + 3*mt*t*t*y2 + t*t*t*y3,
+		})
+	}
+}
+
+func (p *recordingPath) ClosePath() {
+	p.record("Z")
+}
+
+func (p *recordingPath) NewSubPath() {}
+
+// recordArc records the arc from (0, 0) to (x2, y2) with the given parameters.
+func recordArc(rx, ry, xAxisRotation float64, largeArc, sweep bool, x2, y2 float64) *recordingPath {
+	p := &recordingPath{}
+	p.MoveTo(0, 0)
+	arcTo(p, 0, 0, rx, ry, xAxisRotation, largeArc, sweep, x2, y2)
+	return p
+}
+
+// assertOnCircle asserts that the points of the given path lie on the given circle, and returns
+// the minimum and maximum y coordinates of those points.
+func assertOnCircle(t *testing.T, p *recordingPath, cx, cy, r float64) (float64, float64) {
+	t.Helper()
+
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, pt := range p.points {
+		assert.InDelta(t, r, math.Hypot(pt[0]-cx, pt[1]-cy), r*1e-3, "%v", pt)
+		minY, maxY = math.Min(minY, pt[1]), math.Max(maxY, pt[1])
+	}
+	return minY, maxY
+}
+
+func TestArcToDegenerate(t *testing.T) {
+	// An arc with identical endpoints is omitted.
+	p := recordArc(10, 10, 0, false, true, 0, 0)
+	assert.Equal(t, []string{"M 0 0"}, p.segments)
+
+	// An arc with a zero radius is a straight line.
+	p = recordArc(0, 10, 0, false, true, 10, 5)
+	assert.Equal(t, []string{"M 0 0", "L 10 5"}, p.segments)
+
+	p = recordArc(10, 0, 30, true, false, 10, 5)
+	assert.Equal(t, []string{"M 0 0", "L 10 5"}, p.segments)
+}
+
+func TestArcToRadiiScaleUp(t *testing.T) {
+	// Radii that are too small to span the endpoints are scaled up uniformly until they do,
+	// which yields a half ellipse centered on the midpoint of the endpoints.
+	p := recordArc(1, 1, 0, false, true, 10, 0)
+	require.Len(t, p.segments, 3)
+	assert.Equal(t, "C 0 -2.761 2.239 -5 5 -5", p.segments[1])
+	assert.Equal(t, "C 7.761 -5 10 -2.761 10 0", p.segments[2])
+	assertOnCircle(t, p, 5, 0, 5)
+
+	// The large arc flag has no effect on a half ellipse.
+	p2 := recordArc(1, 1, 0, true, true, 10, 0)
+	assert.Equal(t, p.segments, p2.segments)
+
+	// Non-uniform radii keep their ratio. The scaled ellipse is centered at (0, 5) with radii of
+	// 10 and 5.
+	p = recordArc(2, 1, 0, false, false, 0, 10)
+	minX := math.Inf(1)
+	for _, pt := range p.points {
+		minX = math.Min(minX, pt[0])
+		dx, dy := pt[0]/10, (pt[1]-5)/5
+		assert.InDelta(t, 1, math.Hypot(dx, dy), 1e-3, "%v", pt)
+	}
+	assert.InDelta(t, -10, minX, 0.05)
+
+	// Negative radii are treated as positive.
+	p2 = recordArc(-1, -1, 0, false, true, 10, 0)
+	p = recordArc(1, 1, 0, false, true, 10, 0)
+	assert.Equal(t, p.segments, p2.segments)
+}
+
+func TestArcToFlags(t *testing.T) {
+	// The arcs from (0, 0) to (10, 0) with radius 10 lie on circles centered at (5, -8.66) or
+	// (5, 8.66). The flags select the circle and which of its two arcs is drawn.
+	h := 10 * math.Sqrt(3) / 2
+	cases := []struct {
+		largeArc, sweep bool
+		cy              float64
+		minY, maxY      float64
+		segments        int
+	}{
+		{false, false, -h, 0, 10 - h, 1},
+		{false, true, h, h - 10, 0, 1},
+		{true, false, h, 0, h + 10, 4},
+		{true, true, -h, -h - 10, 0, 4},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("large-arc=%v sweep=%v", c.largeArc, c.sweep), func(t *testing.T) {
+			p := recordArc(10, 10, 0, c.largeArc, c.sweep, 10, 0)
+			assert.Len(t, p.segments, 1+c.segments)
+			assert.True(t, strings.HasSuffix(p.segments[len(p.segments)-1], " 10 0"))
+
+			minY, maxY := assertOnCircle(t, p, 5, c.cy, 10)
+			assert.InDelta(t, c.minY, minY, 0.05)
+			assert.InDelta(t, c.maxY, maxY, 0.05)
+		})
+	}
+}
+
+func TestArcToRotation(t *testing.T) {
+	// Rotating the x axis of a circle has no effect.
+	p := recordArc(10, 10, 0, false, true, 10, 0)
+	p2 := recordArc(10, 10, 45, false, true, 10, 0)
+	assert.Equal(t, p.segments, p2.segments)
+
+	// Rotating an ellipse by 90 degrees swaps its radii.
+	p = recordArc(5, 10, 0, false, true, 10, 10)
+	p2 = recordArc(10, 5, 90, false, true, 10, 10)
+	require.Equal(t, len(p.points), len(p2.points))
+	for i := range p.points {
+		assert.InDelta(t, p.points[i][0], p2.points[i][0], 1e-6)
+		assert.InDelta(t, p.points[i][1], p2.points[i][1], 1e-6)
+	}
+}
+
+// recordPath records the segments of the given path data.
+func recordPath(t *testing.T, d string) []string {
+	commands, err := ParsePathCommands(d)
+	require.NoError(t, err)
+
+	p := &recordingPath{}
+	tracePath(p, commands)
+	return p.segments
+}
+
+func TestPathReflection(t *testing.T) {
+	cases := []struct {
+		d        string
+		expected []string
+	}{
+		// The first control point of S reflects the second control point of a preceding C or S.
+		{"M 0 0 C 0 10 10 10 10 0 S 20 -10 20 0", []string{"M 0 0", "C 0 10 10 10 10 0", "C 10 -10 20 -10 20 0"}},
+		{"M 0 0 C 0 10 10 10 10 0 S 20 -10 20 0 S 30 10 30 0", []string{"M 0 0", "C 0 10 10 10 10 0", "C 10 -10 20 -10 20 0", "C 20 10 30 10 30 0"}},
+		{"M 0 0 c 0 10 10 10 10 0 s 10 -10 10 0", []string{"M 0 0", "C 0 10 10 10 10 0", "C 10 -10 20 -10 20 0"}},
+
+		// The control point of T reflects the control point of a preceding Q or T.
+		{"M 0 0 Q 5 10 10 0 T 20 0", []string{"M 0 0", "Q 5 10 10 0", "Q 15 -10 20 0"}},
+		{"M 0 0 Q 5 10 10 0 T 20 0 T 30 0", []string{"M 0 0", "Q 5 10 10 0", "Q 15 -10 20 0", "Q 25 10 30 0"}},
+		{"M 0 0 q 5 10 10 0 t 10 0", []string{"M 0 0", "Q 5 10 10 0", "Q 15 -10 20 0"}},
+
+		// S after a quadratic curve and T after a cubic curve use the current point instead.
+		{"M 0 0 Q 10 10 20 0 S 40 0 50 0", []string{"M 0 0", "Q 10 10 20 0", "C 20 0 40 0 50 0"}},
+		{"M 0 0 C 0 10 10 10 10 0 T 20 0", []string{"M 0 0", "C 0 10 10 10 10 0", "Q 10 0 20 0"}},
+		{"M 0 0 T 10 10 T 20 0", []string{"M 0 0", "Q 0 0 10 10", "Q 20 20 20 0"}},
+
+		// So do S and T after any other command.
+		{"M 0 0 L 10 0 S 20 10 30 0", []string{"M 0 0", "L 10 0", "C 10 0 20 10 30 0"}},
+		{"M 0 0 C 0 10 10 10 10 0 L 20 0 S 30 10 40 0", []string{"M 0 0", "C 0 10 10 10 10 0", "L 20 0", "C 20 0 30 10 40 0"}},
+		{"M 0 0 Q 5 10 10 0 M 20 0 T 30 0", []string{"M 0 0", "Q 5 10 10 0", "M 20 0", "Q 20 0 30 0"}},
+	}
+	for _, c := range cases {
+		t.Run(c.d, func(t *testing.T) {
+			assert.Equal(t, c.expected, recordPath(t, c.d))
+		})
+	}
+}
+
+func TestEllipseAutoRadius(t *testing.T) {
+	// An auto radius takes the value of the other radius.
+	circle := renderElements(t, 40, 40, `<circle cx="20" cy="20" r="10" fill="red"/>`)
+	assert.Equal(t, circle, renderElements(t, 40, 40, `<ellipse cx="20" cy="20" rx="10" fill="red"/>`))
+	assert.Equal(t, circle, renderElements(t, 40, 40, `<ellipse cx="20" cy="20" rx="10" ry="auto" fill="red"/>`))
+	assert.Equal(t, circle, renderElements(t, 40, 40, `<ellipse cx="20" cy="20" rx="auto" ry="10" fill="red"/>`))
+	assert.Equal(t, circle, renderElements(t, 40, 40, `<ellipse cx="20" cy="20" ry="10" fill="red"/>`))
+
+	// Percentages resolve against the viewport's width or height before they are used.
+	assert.Equal(t, circle, renderElements(t, 40, 40, `<ellipse cx="20" cy="20" rx="25%" fill="red"/>`))
+
+	// If both radii are auto, the element is not rendered.
+	img := renderElements(t, 40, 40, `<ellipse cx="20" cy="20" fill="red"/>`)
+	assert.True(t, paintedBounds(img, red).Empty())
+
+	img = renderElements(t, 40, 40, `<ellipse cx="20" cy="20" rx="10" ry="0" fill="red"/>`)
+	assert.True(t, paintedBounds(img, red).Empty())
+}
+
+func TestPolyPoints(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected PolyPoints
+	}{
+		{"", nil},
+		{"10 20", PolyPoints{{10, 20}}},
+		{"10,20 30,40", PolyPoints{{10, 20}, {30, 40}}},
+		{"10-20-30-40", PolyPoints{{10, -20}, {-30, -40}}},
+
+		// The last, odd coordinate of an odd-length list is dropped.
+		{"10", nil},
+		{"10 20 30", PolyPoints{{10, 20}}},
+		{"10 20, 30 40, 50", PolyPoints{{10, 20}, {30, 40}}},
+		{"10 20 30 40,", PolyPoints{{10, 20}, {30, 40}}},
+	}
+	for _, c := range cases {
+		t.Run(c.text, func(t *testing.T) {
+			var points PolyPoints
+			require.NoError(t, points.UnmarshalText([]byte(c.text)))
+			assert.Equal(t, c.expected, points)
+		})
+	}
+}
+
+func TestPolyOddPoints(t *testing.T) {
+	// An odd-length list renders the points before the odd coordinate.
+	for _, element := range []string{"polygon", "polyline"} {
+		t.Run(element, func(t *testing.T) {
+			expected := renderElements(t, 40, 40, `<`+element+` points="10 10 30 10 30 30" fill="red" stroke="blue"/>`)
+			assert.Equal(t, expected, renderElements(t, 40, 40, `<`+element+` points="10 10 30 10 30 30 40" fill="red" stroke="blue"/>`))
+			assert.Equal(t, red, at(expected, 25, 15))
+			assert.Equal(t, uint8(0), at(expected, 15, 25).A)
+		})
+	}
+}
+
+func TestPolyFewPoints(t *testing.T) {
+	// Polylines and polygons with fewer than two points are not rendered, even with round caps.
+	const paint = `fill="red" stroke="red" stroke-width="10" stroke-linecap="round"`
+	for _, element := range []string{
+		`<polyline ` + paint + `/>`,
+		`<polyline points="" ` + paint + `/>`,
+		`<polyline points="20 20" ` + paint + `/>`,
+		`<polyline points="20 20 30" ` + paint + `/>`,
+		`<polygon points="20 20" ` + paint + `/>`,
+	} {
+		t.Run(element, func(t *testing.T) {
+			img := renderElements(t, 40, 40, element)
+			assert.Equal(t, uint8(0), at(img, 20, 20).A)
+			assert.True(t, paintedBounds(img, red).Empty())
+		})
+	}
+
+	// Two points are enough for a stroke. The round caps extend 5 units past the ends, less
+	// their antialiased tips.
+	img := renderElements(t, 40, 40, `<polyline points="10 20 30 20" `+paint+`/>`)
+	assert.Equal(t, image.Rect(6, 15, 34, 25), paintedBounds(img, red))
+}
+
+func TestUseSymbolViewBox(t *testing.T) {
+	// The symbol's view box is fit into the viewport established by the use element.
+	cases := []struct {
+		attrs    string
+		expected image.Rectangle
+	}{
+		{``, image.Rect(10, 0, 30, 20)},
+		{`preserveAspectRatio="xMinYMin"`, image.Rect(0, 0, 20, 20)},
+		{`preserveAspectRatio="xMaxYMax meet"`, image.Rect(20, 0, 40, 20)},
+		{`preserveAspectRatio="xMidYMid slice"`, image.Rect(0, 0, 40, 20)},
+		{`preserveAspectRatio="none"`, image.Rect(0, 0, 40, 20)},
+	}
+	for _, c := range cases {
+		t.Run(c.attrs, func(t *testing.T) {
+			img := renderElements(t, 60, 40, `<defs>
+				<symbol id="s" viewBox="0 0 10 10" `+c.attrs+`>
+					<rect width="10" height="10" fill="red"/>
+				</symbol>
+			</defs>
+			<use href="#s" width="40" height="20"/>`)
+			assert.Equal(t, c.expected, paintedBounds(img, red))
+		})
+	}
+
+	// A sliced view box is clipped to the viewport: only the middle of the symbol is visible.
+	img := renderElements(t, 60, 40, `<defs>
+		<symbol id="s" viewBox="0 0 10 10" preserveAspectRatio="xMidYMid slice">
+			<rect width="10" height="5" fill="blue"/>
+			<rect y="5" width="10" height="5" fill="red"/>
+		</symbol>
+	</defs>
+	<use href="#s" width="40" height="20"/>`)
+	assert.Equal(t, image.Rect(0, 0, 40, 10), paintedBounds(img, blue))
+	assert.Equal(t, image.Rect(0, 10, 40, 20), paintedBounds(img, red))
+}
+
+func TestUseOffset(t *testing.T) {
+	// x and y translate the referenced element after the use element's transform.
+	cases := []struct {
+		attrs    string
+		expected image.Rectangle
+	}{
+		{``, image.Rect(0, 0, 10, 10)},
+		{`x="10" y="5"`, image.Rect(10, 5, 20, 15)},
+		{`x="25%" y="25%"`, image.Rect(15, 10, 25, 20)},
+		{`x="10" transform="translate(5 0) scale(2)"`, image.Rect(25, 0, 45, 20)},
+	}
+	for _, c := range cases {
+		t.Run(c.attrs, func(t *testing.T) {
+			img := renderElements(t, 60, 40, `<defs><rect id="r" width="10" height="10" fill="red"/></defs>
+			<use href="#r" `+c.attrs+`/>`)
+			assert.Equal(t, c.expected, paintedBounds(img, red))
+		})
+	}
+
+	// The offset moves the symbol's viewport.
+	img := renderElements(t, 60, 40, `<defs>
+		<symbol id="s" width="10" height="10"><rect width="20" height="20" fill="red"/></symbol>
+	</defs>
+	<use href="#s" x="10" y="5"/>`)
+	assert.Equal(t, image.Rect(10, 5, 20, 15), paintedBounds(img, red))
+}
+
+func TestUseSize(t *testing.T) {
+	const symbol = `<defs>
+		<symbol id="s" width="10" height="10" viewBox="0 0 10 10"><rect width="10" height="10" fill="red"/></symbol>
+		<rect id="r" width="10" height="10" fill="blue"/>
+	</defs>`
+
+	// Without a width and height, the symbol's own size is used.
+	img := renderElements(t, 60, 40, symbol+`<use href="#s"/>`)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), paintedBounds(img, red))
+
+	// The use element's width and height override those of the symbol.
+	img = renderElements(t, 60, 40, symbol+`<use href="#s" width="30" height="30"/>`)
+	assert.Equal(t, image.Rect(0, 0, 30, 30), paintedBounds(img, red))
+
+	img = renderElements(t, 60, 40, symbol+`<use href="#s" width="30"/>`)
+	assert.Equal(t, image.Rect(10, 0, 20, 10), paintedBounds(img, red))
+
+	// A zero size disables rendering.
+	img = renderElements(t, 60, 40, symbol+`<use href="#s" width="0"/>`)
+	assert.True(t, paintedBounds(img, red).Empty())
+
+	// The width and height have no effect on other elements.
+	img = renderElements(t, 60, 40, symbol+`<use href="#r" width="30" height="30"/>`)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), paintedBounds(img, blue))
+}
+
+func TestUseInheritance(t *testing.T) {
+	// The referenced element inherits properties from the use element rather than from its
+	// own ancestors.
+	img := renderElements(t, 60, 40, `<defs>
+		<g fill="blue"><rect id="r" width="10" height="10"/></g>
+		<g fill="blue"><symbol id="s"><rect width="10" height="10"/></symbol></g>
+	</defs>
+	<use href="#r" fill="red"/>
+	<g fill="red"><use href="#s" x="20"/></g>`)
+	assert.True(t, paintedBounds(img, blue).Empty())
+	assert.Equal(t, image.Rect(0, 0, 30, 10), paintedBounds(img, red))
+
+	// Properties set on the referenced element itself still take precedence.
+	img = renderElements(t, 60, 40, `<defs><rect id="r" width="10" height="10" fill="blue"/></defs>
+	<use href="#r" fill="red"/>`)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), paintedBounds(img, blue))
+}
+
+func TestUseCircular(t *testing.T) {
+	// Use elements that reference themselves, directly or indirectly, are not rendered. The
+	// other contents of the referenced elements are.
+	cases := []struct {
+		name, elements string
+		expected       image.Rectangle
+	}{
+		{"self", `<use id="u" href="#u"/><rect width="10" height="10" fill="red"/>`, image.Rect(0, 0, 10, 10)},
+		{"ancestor", `<g id="g"><rect width="10" height="10" fill="red"/><use href="#g" x="20"/></g>`, image.Rect(0, 0, 10, 10)},
+		{"indirect", `<defs>
+			<g id="a"><rect width="10" height="10" fill="red"/><use href="#b" x="20"/></g>
+			<g id="b"><use href="#a" y="20"/></g>
+		</defs>
+		<use href="#a"/>`, image.Rect(0, 0, 10, 10)},
+		{"symbol", `<defs>
+			<symbol id="s" overflow="visible"><rect width="10" height="10" fill="red"/><use href="#s" x="20"/></symbol>
+		</defs>
+		<use href="#s"/>`, image.Rect(0, 0, 10, 10)},
+
+		// Repeated references that do not form a cycle are rendered.
+		{"repeated", `<defs>
+			<rect id="r" width="10" height="10" fill="red"/>
+			<g id="g"><use href="#r"/><use href="#r" x="20"/></g>
+		</defs>
+		<use href="#g"/>`, image.Rect(0, 0, 30, 10)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			img := renderElements(t, 60, 40, c.elements)
+			assert.Equal(t, c.expected, paintedBounds(img, red))
+		})
+	}
+}
+
+func TestViewBoxTransform(t *testing.T) {
+	// The view box is 10x20 at (5, 10) and the viewport is 60x80 at (1, 2), so the view box is
+	// scaled by 4 to meet the viewport, leaving 20 units of free space horizontally, or by 6 to
+	// slice it, overflowing by 40 units vertically.
+	cases := []struct {
+		par                                    PreserveAspectRatio
+		translateX, translateY, scaleX, scaleY float64
+	}{
+		{PreserveAspectRatio{}, -9, -38, 4, 4},
+		{PreserveAspectRatio{Align: "none"}, -29, -38, 6, 4},
+		{PreserveAspectRatio{Align: "none", MeetOrSlice: "slice"}, -29, -38, 6, 4},
+
+		{PreserveAspectRatio{Align: "xMinYMin"}, -19, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMidYMin"}, -9, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMaxYMin"}, 1, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMinYMid", MeetOrSlice: "meet"}, -19, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMidYMid", MeetOrSlice: "meet"}, -9, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMaxYMid", MeetOrSlice: "meet"}, 1, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMinYMax", MeetOrSlice: "meet"}, -19, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMidYMax", MeetOrSlice: "meet"}, -9, -38, 4, 4},
+		{PreserveAspectRatio{Align: "xMaxYMax", MeetOrSlice: "meet"}, 1, -38, 4, 4},
+
+		{PreserveAspectRatio{Align: "xMinYMin", MeetOrSlice: "slice"}, -29, -58, 6, 6},
+		{PreserveAspectRatio{Align: "xMidYMin", MeetOrSlice: "slice"}, -29, -58, 6, 6},
+		{PreserveAspectRatio{Align: "xMaxYMin", MeetOrSlice: "slice"}, -29, -58, 6, 6},
+		{PreserveAspectRatio{Align: "xMinYMid", MeetOrSlice: "slice"}, -29, -78, 6, 6},
+		{PreserveAspectRatio{Align: "xMidYMid", MeetOrSlice: "slice"}, -29, -78, 6, 6},
+		{PreserveAspectRatio{Align: "xMaxYMid", MeetOrSlice: "slice"}, -29, -78, 6, 6},
+		{PreserveAspectRatio{Align: "xMinYMax", MeetOrSlice: "slice"}, -29, -98, 6, 6},
+		{PreserveAspectRatio{Align: "xMidYMax", MeetOrSlice: "slice"}, -29, -98, 6, 6},
+		{PreserveAspectRatio{Align: "xMaxYMax", MeetOrSlice: "slice"}, -29, -98, 6, 6},
+	}
+	for _, c := range cases {
+		t.Run(c.par.Align+" "+c.par.MeetOrSlice, func(t *testing.T) {
+			translateX, translateY, scaleX, scaleY := viewBoxTransform(&ViewBox{MinX: 5, MinY: 10, Width: 10, Height: 20}, c.par, 1, 2, 60, 80)
+			assert.Equal(t, c.translateX, translateX)
+			assert.Equal(t, c.translateY, translateY)
+			assert.Equal(t, c.scaleX, scaleX)
+			assert.Equal(t, c.scaleY, scaleY)
+		})
+	}
+}
+
+func TestRootViewBox(t *testing.T) {
+	// The root element's view box is fit into the viewport given by its width and height. The
+	// red square covers the bottom right quarter of a view box at the origin.
+	cases := []struct {
+		attrs    string
+		expected image.Rectangle
+	}{
+		{`viewBox="0 0 10 10"`, image.Rect(20, 10, 30, 20)},
+		{`viewBox="5 5 10 10"`, image.Rect(10, 0, 20, 10)},
+		{`viewBox="0 0 10 10" preserveAspectRatio="xMinYMid"`, image.Rect(10, 10, 20, 20)},
+		{`viewBox="0 0 10 10" preserveAspectRatio="xMaxYMid meet"`, image.Rect(30, 10, 40, 20)},
+		{`viewBox="0 0 10 10" preserveAspectRatio="xMidYMax slice"`, image.Rect(20, 0, 40, 20)},
+		{`viewBox="0 0 10 10" preserveAspectRatio="none"`, image.Rect(20, 10, 40, 20)},
+	}
+	for _, c := range cases {
+		t.Run(c.attrs, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="20" `+c.attrs+`>
+				<rect x="5" y="5" width="5" height="5" fill="red"/>
+			</svg>`)
+			assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+			assert.Equal(t, c.expected, paintedBounds(img, red))
+		})
+	}
+
+	// A sliced view box is clipped to the viewport, so only the middle of the view box is
+	// visible.
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="20" viewBox="0 0 10 10" preserveAspectRatio="xMidYMid slice">
+		<rect width="10" height="5" fill="blue"/>
+		<rect y="5" width="10" height="5" fill="red"/>
+	</svg>`)
+	assert.Equal(t, image.Rect(0, 0, 40, 10), paintedBounds(img, blue))
+	assert.Equal(t, image.Rect(0, 10, 40, 20), paintedBounds(img, red))
+
+	// Without a width and height, the document is the size of its view box.
+	img = renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="5 5 30 20"><rect x="5" y="5" width="10" height="10" fill="red"/></svg>`)
+	assert.Equal(t, image.Rect(0, 0, 30, 20), img.Bounds())
+	assert.Equal(t, image.Rect(0, 0, 10, 10), paintedBounds(img, red))
+}
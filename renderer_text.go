@@ -0,0 +1,566 @@
+package svg
+
+import (
+	"math"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font/sfnt"
+)
+
+// textGlyph is a single addressable character of a text element and the glyph that renders
+// it.
+type textGlyph struct {
+	r     rune
+	glyph sfnt.GlyphIndex
+	font  *textFont
+
+	// stack holds the text content elements between the text element and the character,
+	// outermost first. The character's properties are resolved against these elements.
+	stack []*element
+
+	// anchor is the value of the text-anchor property for the character.
+	anchor Ident
+
+	// x and y are the character's absolute position, if any, and dx and dy are its relative
+	// position. Unspecified absolute positions are NaN. rotate is the character's
+	// supplemental rotation in degrees.
+	x, y, dx, dy, rotate float64
+
+	// advance is the advance width of the glyph, and spacing is the letter and word spacing
+	// that follows it. scale is the horizontal scale of the glyph.
+	advance, spacing, scale float64
+
+	// baseline is the distance that the glyph is moved down from the current text position
+	// in order to align its baseline and apply any baseline shift.
+	baseline float64
+
+	// (px, py) is the position of the glyph's origin in the text element's user space and
+	// angle is its rotation in radians.
+	px, py, angle float64
+
+	// chunk is true if the character begins a new text chunk.
+	chunk bool
+
+	// path is the text path along which the character is laid out, if any. If the character
+	// is on a path, (px, py) is given relative to the path until it is placed on the path.
+	path *textPathLayout
+
+	// hidden is true if the character is not rendered.
+	hidden bool
+}
+
+// textSpan records the positioning attributes of a text content element and the range of
+// characters that it contains.
+type textSpan struct {
+	x, y, dx, dy, rotate []float64
+	start, end           int
+
+	// textLength is the author's computation of the length of the element's text, if it is
+	// positive, and lengthAdjust is the value of the element's lengthAdjust attribute.
+	textLength   float64
+	lengthAdjust string
+}
+
+// textLayout accumulates the characters of a text element.
+type textLayout struct {
+	glyphs []textGlyph
+	spans  []*textSpan
+
+	// base is the depth of the text element in the renderer's stack.
+	base int
+
+	// path is the layout path of the innermost enclosing textPath element, if any.
+	path *textPathLayout
+
+	// font is the font of the innermost enclosing text content element, and baseline is the
+	// distance that the element's alphabetic baseline lies below the current text position,
+	// including any baseline shifts.
+	font     *textFont
+	baseline float64
+
+	// collapse is true if a following space would be collapsed, and trailing is true if the
+	// last character is a collapsible space.
+	collapse, trailing bool
+}
+
+// renderText renders a text element. The element's character data and the contents of its
+// tspan and textPath children are laid out in document order, and each character is painted
+// using the properties of the element that contains it.
+//
+// See https://www.w3.org/TR/SVG2/text.html#TextLayoutAlgorithm for details.
+func (r *renderer) renderText(ctx *gg.Context, e *Text) error {
+	ctx.Push()
+	defer ctx.Pop()
+
+	if !r.transform(ctx, e) {
+		return nil
+	}
+
+	r.push(e, r.width(), r.height())
+	defer r.pop()
+
+	glyphs, err := r.layoutText(e)
+	if err != nil {
+		return err
+	}
+	return r.paintText(ctx, glyphs)
+}
+
+// layoutText computes the positions of the glyphs of the text element at the top of the stack.
+func (r *renderer) layoutText(e *Text) ([]textGlyph, error) {
+	l := &textLayout{base: len(r.stack), collapse: true}
+	if err := r.collectText(l, e, e.Children); err != nil {
+		return nil, err
+	}
+
+	// A collapsible space at the end of the text is removed.
+	if l.trailing {
+		l.glyphs = l.glyphs[:len(l.glyphs)-1]
+		for _, s := range l.spans {
+			if s.end > len(l.glyphs) {
+				s.end = len(l.glyphs)
+			}
+		}
+	}
+
+	// Apply the positioning attributes of each element to the characters it contains. The
+	// spans are in document order, so the attributes of descendants override those of their
+	// ancestors. If an element specifies fewer rotations than it has characters, its last
+	// rotation applies to the remaining characters.
+	for _, s := range l.spans {
+		glyphs := l.glyphs[s.start:s.end]
+		for i := 0; i < len(glyphs); i++ {
+			g := &glyphs[i]
+			if i < len(s.x) {
+				g.x = s.x[i]
+			}
+			if i < len(s.y) {
+				g.y = s.y[i]
+			}
+			if i < len(s.dx) {
+				g.dx = s.dx[i]
+			}
+			if i < len(s.dy) {
+				g.dy = s.dy[i]
+			}
+			if n := len(s.rotate); n != 0 {
+				g.rotate = s.rotate[n-1]
+				if i < n {
+					g.rotate = s.rotate[i]
+				}
+			}
+		}
+	}
+
+	// Lay out the characters along the baseline. Each character with an absolute position
+	// begins a new text chunk, as does each character that begins or follows the contents of
+	// a textPath element.
+	//
+	// Characters on a path are laid out relative to the start of the path. Absolute x
+	// positions give distances along the path, and absolute y positions are ignored. Once
+	// the path ends, layout continues from the end of its last character.
+	x, y := 0.0, 0.0
+	var path *textPathLayout
+	for i := range l.glyphs {
+		g := &l.glyphs[i]
+
+		entering, leaving := g.path != path && g.path != nil, g.path != path && path != nil
+		if leaving && path.path != nil {
+			x, y, _ = path.path.at(x + path.offset)
+		}
+		if entering {
+			x, y = 0, 0
+		}
+		path = g.path
+
+		g.chunk = i == 0 || entering || leaving || !math.IsNaN(g.x) || !math.IsNaN(g.y) && path == nil
+		if !math.IsNaN(g.x) {
+			x = g.x
+		}
+		if !math.IsNaN(g.y) && path == nil {
+			y = g.y
+		}
+
+		// Kerning applies between adjacent characters in the same run.
+		if i > 0 && !g.chunk && g.dx == 0 {
+			if prev := &l.glyphs[i-1]; prev.font == g.font {
+				x += g.font.kern(prev.glyph, g.glyph)
+			}
+		}
+
+		x, y = x+g.dx, y+g.dy
+		g.px, g.py, g.angle = x, y, gg.Radians(g.rotate)
+		x += g.advance + g.spacing
+	}
+
+	adjustTextLength(l.glyphs, l.spans)
+	anchorText(l.glyphs)
+	placeOnPath(l.glyphs)
+	return l.glyphs, nil
+}
+
+// adjustTextLength adjusts the positions of the glyphs in each element with a textLength
+// attribute so that the element's text spans the given length. Descendants are adjusted before
+// their ancestors.
+//
+// If lengthAdjust is spacing, which is the default, the extra space is distributed evenly
+// between the element's characters. If lengthAdjust is spacingAndGlyphs, the element's glyphs
+// and the spaces between them are scaled horizontally. In either case, the characters that
+// follow the element in the same text chunk are shifted by the adjustment.
+func adjustTextLength(glyphs []textGlyph, spans []*textSpan) {
+	for i := len(spans) - 1; i >= 0; i-- {
+		s := spans[i]
+		if s.textLength <= 0 || s.start == s.end {
+			continue
+		}
+		chars := glyphs[s.start:s.end]
+
+		left, right := math.Inf(1), math.Inf(-1)
+		for _, g := range chars {
+			left, right = math.Min(left, g.px), math.Max(right, g.px+g.advance)
+		}
+		length := right - left
+		if length <= 0 {
+			continue
+		}
+
+		delta := s.textLength - length
+		switch {
+		case s.lengthAdjust == "spacingAndGlyphs":
+			k := s.textLength / length
+			for j := range chars {
+				g := &chars[j]
+				g.px = left + (g.px-left)*k
+				g.advance, g.spacing, g.scale = g.advance*k, g.spacing*k, g.scale*k
+			}
+		case len(chars) > 1:
+			step := delta / float64(len(chars)-1)
+			for j := range chars {
+				chars[j].px += float64(j) * step
+			}
+		default:
+			delta = 0
+		}
+
+		for j := s.end; j < len(glyphs) && !glyphs[j].chunk; j++ {
+			glyphs[j].px += delta
+		}
+	}
+}
+
+// anchorText aligns each text chunk with its start position according to the text-anchor
+// property of the chunk's first character.
+func anchorText(glyphs []textGlyph) {
+	for start := 0; start < len(glyphs); {
+		end := start + 1
+		for end < len(glyphs) && !glyphs[end].chunk {
+			end++
+		}
+		chunk := glyphs[start:end]
+		start = end
+
+		left, right := math.Inf(1), math.Inf(-1)
+		for _, g := range chunk {
+			left, right = math.Min(left, g.px), math.Max(right, g.px+g.advance)
+		}
+
+		var shift float64
+		switch chunk[0].anchor {
+		case "middle":
+			shift = -(right - left) / 2
+		case "end":
+			shift = -(right - left)
+		default:
+			continue
+		}
+		for i := range chunk {
+			chunk[i].px += shift
+		}
+	}
+}
+
+// textPositions computes the positioning attributes of the text content element at the top of
+// the stack. Each list holds one value per character.
+func (r *renderer) textPositions(x, y, dx, dy, rotate LengthPercentageNumbers, textLength LengthPercentageNumber, lengthAdjust string) *textSpan {
+	compute := func(parent float64, l LengthPercentageNumbers) []float64 {
+		values := make([]float64, len(l.Values))
+		for i, v := range l.Values {
+			values[i] = r.computeLengthPercentageNumber(parent, v)
+		}
+		return values
+	}
+	return &textSpan{
+		x:      compute(r.width(), x),
+		y:      compute(r.height(), y),
+		dx:     compute(r.width(), dx),
+		dy:     compute(r.height(), dy),
+		rotate: compute(0, rotate),
+
+		textLength:   r.computeLengthPercentageNumber(r.width(), textLength),
+		lengthAdjust: lengthAdjust,
+	}
+}
+
+// collectText adds the characters of a text content element to the layout. The element must
+// be at the top of the stack.
+func (r *renderer) collectText(l *textLayout, e Element, children []any) error {
+	var span *textSpan
+	switch e := e.(type) {
+	case *Text:
+		span = r.textPositions(e.X, e.Y, e.Dx, e.Dy, e.Rotate, e.TextLength, e.LengthAdjust)
+	case *TSpan:
+		span = r.textPositions(e.X, e.Y, e.Dx, e.Dy, e.Rotate, e.TextLength, e.LengthAdjust)
+	case *TextPath:
+		span = &textSpan{
+			textLength:   r.computeLengthPercentageNumber(r.width(), e.TextLength),
+			lengthAdjust: e.LengthAdjust,
+		}
+
+		path := l.path
+		l.path = r.computeTextPath(e)
+		defer func() { l.path = path }()
+	}
+	span.start = len(l.glyphs)
+	l.spans = append(l.spans, span)
+
+	// Each element's alignment baseline is aligned with the same baseline of its parent, or
+	// with the current text position if it has no parent, and then shifted. Baseline shifts
+	// therefore accumulate through nested elements.
+	font, err := r.textFont()
+	if err != nil {
+		return err
+	}
+	parent, baseline := l.font, l.baseline
+	name := r.computeAlignmentBaseline()
+	l.font, l.baseline = font, font.baseline(name)-r.computeBaselineShift()
+	if parent != nil {
+		l.baseline += baseline - parent.baseline(name)
+	}
+	defer func() { l.font, l.baseline = parent, baseline }()
+
+	for _, c := range children {
+		switch c := c.X.(type) {
+		case *CharData:
+			if err := r.collectCharData(l, c.Value); err != nil {
+				return err
+			}
+		case *TSpan:
+			if err := r.collectChild(l, c, c.Children); err != nil {
+				return err
+			}
+		case *TextPath:
+			if err := r.collectChild(l, c, c.Children); err != nil {
+				return err
+			}
+		}
+	}
+
+	span.end = len(l.glyphs)
+	return nil
+}
+
+// collectChild adds the characters of a child text content element to the layout.
+func (r *renderer) collectChild(l *textLayout, e Element, children []any) error {
+	r.push(e, r.width(), r.height())
+	defer r.pop()
+
+	return r.collectText(l, e, children)
+}
+
+// collectCharData adds the characters of a run of character data to the layout. The character
+// data belongs to the element at the top of the stack.
+//
+// Unless white space is preserved by xml:space or the white-space property, newlines and tabs
+// are converted into spaces, consecutive spaces are collapsed into a single space, and spaces
+// at the start and end of the text element are removed. If white space is preserved, newlines
+// and tabs are still converted into spaces.
+func (r *renderer) collectCharData(l *textLayout, text string) error {
+	preserve := r.getXMLSpace() == "preserve"
+	switch r.getWhiteSpace() {
+	case "pre", "pre-wrap", "break-spaces":
+		preserve = true
+	}
+
+	font := l.font
+
+	letterSpacing := 0.0
+	if ls := r.getLetterSpacing(); ls != nil && ls.Ident == "" {
+		letterSpacing = r.computeLength(ls.Length)
+	}
+	wordSpacing := 0.0
+	if ws := r.getWordSpacing(); ws != nil && ws.Ident == "" {
+		wordSpacing = r.computeLength(ws.Length)
+	}
+
+	stack := append([]*element(nil), r.stack[l.base:]...)
+	anchor := r.getTextAnchor()
+	baseline := l.baseline
+
+	for _, c := range text {
+		switch c {
+		case '\n', '\r', '\t':
+			c = ' '
+		}
+
+		collapsible := !preserve && c == ' '
+		if collapsible && l.collapse {
+			continue
+		}
+		l.collapse, l.trailing = collapsible, collapsible
+
+		glyph := font.glyphIndex(c)
+		spacing := letterSpacing
+		if c == ' ' {
+			spacing += wordSpacing
+		}
+		l.glyphs = append(l.glyphs, textGlyph{
+			r:        c,
+			glyph:    glyph,
+			font:     font,
+			stack:    stack,
+			anchor:   anchor,
+			x:        math.NaN(),
+			y:        math.NaN(),
+			advance:  font.advance(glyph),
+			spacing:  spacing,
+			scale:    1,
+			baseline: baseline,
+			path:     l.path,
+		})
+	}
+	return nil
+}
+
+// computeAlignmentBaseline returns the baseline of the text content element at the top of the
+// stack that is aligned with the same baseline of its parent, or with the current text position
+// if the element is a text element. This is the element's alignment-baseline, if any, and
+// otherwise its dominant-baseline. alignment-baseline is not inherited, so only the element's
+// own value applies, and it does not apply to text elements.
+func (r *renderer) computeAlignmentBaseline() Ident {
+	if _, ok := r.top().Element.(*Text); !ok {
+		switch baseline := r.top().attrs().AlignmentBaseline; baseline {
+		case "", "auto", "baseline":
+		default:
+			return baseline
+		}
+	}
+
+	switch baseline := r.getDominantBaseline(); baseline {
+	case "", "auto", "use-script", "no-change", "reset-size":
+		return "alphabetic"
+	default:
+		return baseline
+	}
+}
+
+// computeBaselineShift returns the baseline shift of the text content element at the top of the
+// stack in user units. Positive shifts raise the baseline. baseline-shift is not inherited, so
+// only the element's own value applies.
+//
+// The sub and super keywords shift the baseline by a fifth and a third of the font size,
+// respectively, and percentages refer to the font size.
+func (r *renderer) computeBaselineShift() float64 {
+	bs := r.top().attrs().BaselineShift
+	switch {
+	case bs == nil || bs.Ident == "baseline":
+		return 0
+	case bs.Ident == "sub":
+		return -r.computeFontSize() / 5
+	case bs.Ident == "super":
+		return r.computeFontSize() / 3
+	case bs.Ident != "":
+		return 0
+	default:
+		return r.computeLengthPercentage(r.computeFontSize(), bs.LengthPercentage)
+	}
+}
+
+// glyphTransform returns a function that maps points in a glyph's coordinate space into the
+// text element's user space.
+func glyphTransform(g *textGlyph) func(x, y float64) (float64, float64) {
+	if g.path != nil && g.path.stretch {
+		return g.path.warp(g)
+	}
+
+	sin, cos := math.Sincos(g.angle)
+	return func(x, y float64) (float64, float64) {
+		return g.px + x*cos - y*sin, g.py + x*sin + y*cos
+	}
+}
+
+// textBounds returns the bounding box of the given glyphs. The bounding box of each glyph
+// spans its advance width and the ascent and descent of its font.
+func textBounds(glyphs []textGlyph) boundingBox {
+	var bounds boundingBox
+	for i := range glyphs {
+		g := &glyphs[i]
+		if g.hidden {
+			continue
+		}
+		ascent, descent := g.font.metrics()
+		transform := glyphTransform(g)
+		for _, p := range [][2]float64{{0, -ascent}, {g.advance, -ascent}, {g.advance, descent}, {0, descent}} {
+			bounds.addPoint(transform(p[0], p[1]+g.baseline))
+		}
+	}
+	return bounds
+}
+
+// paintText fills and strokes the given glyphs. Each run of glyphs that belong to the same
+// element is painted using that element's properties. Paint servers are applied using the
+// bounding box of the text element as a whole.
+func (r *renderer) paintText(ctx *gg.Context, glyphs []textGlyph) error {
+	bounds := textBounds(glyphs)
+
+	base := r.stack
+	defer func() { r.stack = base }()
+
+	for start := 0; start < len(glyphs); {
+		end := start + 1
+		for end < len(glyphs) && sameStack(glyphs[end].stack, glyphs[start].stack) {
+			end++
+		}
+		run := glyphs[start:end]
+		start = end
+
+		r.stack = append(base[:len(base):len(base)], run[0].stack...)
+
+		stroke := &strokePath{path: ctx, scale: strokeScale(currentMatrix(ctx))}
+		path := &boundsPath{path: stroke}
+		ctx.ClearPath()
+		for i := range run {
+			g := &run[i]
+			if g.hidden {
+				continue
+			}
+			transform := glyphTransform(g)
+			g.font.outline(path, g.glyph, func(x, y float64) (float64, float64) {
+				return transform(x*g.scale, y+g.baseline)
+			})
+		}
+
+		if err := r.setFill(ctx, bounds); err != nil {
+			return err
+		}
+		ctx.FillPreserve()
+		err := r.stroke(ctx, stroke, bounds, 0, path.length)
+		ctx.ClearPath()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameStack returns true if the given element stacks are identical.
+func sameStack(a, b []*element) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
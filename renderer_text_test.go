@@ -0,0 +1,366 @@
+package svg
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextContent(t *testing.T) {
+	var text Text
+	err := xml.Unmarshal([]byte(`<text x="10">a <tspan fill="red">b<!-- c -->c</tspan><textPath href="#p">d</textPath> e</text>`), &text)
+	require.NoError(t, err)
+
+	require.Len(t, text.Children, 4)
+	assert.Equal(t, "a ", text.Children[0].X.(*CharData).Value)
+	assert.Equal(t, " e", text.Children[3].X.(*CharData).Value)
+
+	tspan := text.Children[1].X.(*TSpan)
+	assert.Equal(t, &Paint{Color: &color.RGBA{R: 0xff, A: 0xff}}, tspan.Fill)
+	require.Len(t, tspan.Children, 1)
+	assert.Equal(t, "bc", tspan.Children[0].X.(*CharData).Value)
+
+	textPath := text.Children[2].X.(*TextPath)
+	assert.Equal(t, "#p", textPath.Href)
+
+	// The deprecated Value fields hold each element's own character data.
+	assert.Equal(t, "a  e", text.Value)
+	assert.Equal(t, "bc", tspan.Value)
+	assert.Equal(t, "d", textPath.Value)
+}
+
+func TestTextSpans(t *testing.T) {
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">
+		<text x="10" y="60" font-size="50" fill="blue">II<tspan fill="red">II</tspan>II</text>
+	</svg>`)
+
+	// The tspan's characters follow the text element's characters and are followed by the
+	// remaining characters of the text element.
+	r := paintedBounds(img, red)
+	require.False(t, r.Empty())
+
+	var before, after image.Rectangle
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		column := paintedBounds(subImage(img, image.Rect(x, 0, x+1, b.Max.Y)), blue)
+		switch {
+		case column.Empty():
+		case x < r.Min.X:
+			before = before.Union(column)
+		case x >= r.Max.X:
+			after = after.Union(column)
+		default:
+			t.Fatalf("blue pixel in tspan at x = %v", x)
+		}
+	}
+	assert.False(t, before.Empty())
+	assert.False(t, after.Empty())
+	assert.Greater(t, before.Min.X, 9)
+}
+
+func TestTextSpanPosition(t *testing.T) {
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200">
+		<text x="10" y="60" font-size="50" fill="blue">I<tspan x="100" dy="80" fill="red">I</tspan></text>
+	</svg>`)
+
+	r := paintedBounds(img, red)
+	assert.GreaterOrEqual(t, r.Min.X, 100)
+	assert.Less(t, r.Min.X, 120)
+	assert.Greater(t, r.Min.Y, 100)
+
+	bl := paintedBounds(img, blue)
+	assert.Less(t, bl.Max.X, 50)
+	assert.Less(t, bl.Max.Y, 70)
+}
+
+func TestTextWhiteSpace(t *testing.T) {
+	collapsed := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">
+		<text x="10" y="60" font-size="50">
+			I
+			<tspan>  I  </tspan>
+		</text>
+	</svg>`)
+	expected := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">
+		<text x="10" y="60" font-size="50">I I</text>
+	</svg>`)
+	assert.Equal(t, expected, collapsed)
+
+	preserved := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">
+		<text x="10" y="60" font-size="50" xml:space="preserve">I  I</text>
+	</svg>`)
+	assert.NotEqual(t, expected, preserved)
+}
+
+func TestTextAnchor(t *testing.T) {
+	for _, c := range []struct {
+		anchor   string
+		min, max int
+	}{
+		{"start", 100, 150},
+		{"middle", 75, 125},
+		{"end", 50, 100},
+	} {
+		t.Run(c.anchor, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">
+				<text x="100" y="60" font-size="50" fill="blue"><tspan text-anchor="`+c.anchor+`">II</tspan></text>
+			</svg>`)
+
+			b := paintedBounds(img, blue)
+			require.False(t, b.Empty())
+			assert.GreaterOrEqual(t, b.Min.X, c.min)
+			assert.LessOrEqual(t, b.Max.X, c.max)
+		})
+	}
+}
+
+// columns returns the x coordinates of the columns of the given image that contain opaque
+// pixels of the given color.
+func columns(img image.Image, c color.NRGBA) map[int]bool {
+	result := map[int]bool{}
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		if !paintedBounds(subImage(img, image.Rect(x, b.Min.Y, x+1, b.Max.Y)), c).Empty() {
+			result[x] = true
+		}
+	}
+	return result
+}
+
+// anyIn returns true if any of the given columns lies in [min, max).
+func anyIn(cols map[int]bool, min, max int) bool {
+	for x := min; x < max; x++ {
+		if cols[x] {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTextPositionLists(t *testing.T) {
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">
+		<text x="10 50 90" y="60" font-size="50" fill="blue">I<tspan x="150">I</tspan>I</text>
+	</svg>`)
+
+	// The tspan's x attribute overrides the second value of the text element's x attribute.
+	cols := columns(img, blue)
+	assert.True(t, anyIn(cols, 10, 30))
+	assert.False(t, anyIn(cols, 30, 90))
+	assert.True(t, anyIn(cols, 90, 110))
+	assert.False(t, anyIn(cols, 110, 150))
+	assert.True(t, anyIn(cols, 150, 170))
+}
+
+func TestTextRelativePositionLists(t *testing.T) {
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200">
+		<text x="10" y="60" dx="0 50" dy="0 80" font-size="50" fill="blue">I<tspan fill="red">II</tspan></text>
+	</svg>`)
+
+	// The second character is offset by (50, 80), and the third follows it.
+	bl, r := paintedBounds(img, blue), paintedBounds(img, red)
+	assert.Less(t, bl.Max.Y, 70)
+	assert.Greater(t, r.Min.Y, 100)
+	assert.Greater(t, r.Min.X, bl.Max.X+40)
+
+	cols := columns(img, red)
+	assert.True(t, anyIn(cols, r.Min.X, r.Min.X+10))
+	assert.True(t, anyIn(cols, r.Max.X-10, r.Max.X))
+}
+
+func TestTextRotate(t *testing.T) {
+	render := func(rotate string) image.Image {
+		return renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200">
+			<text x="50" y="60" font-size="50" fill="blue" rotate="`+rotate+`">III</text>
+		</svg>`)
+	}
+
+	// The last rotation applies to the remaining characters.
+	assert.Equal(t, render("90 90 90"), render("90"))
+	assert.Equal(t, render("0 90 90"), render("0 90"))
+	assert.NotEqual(t, render("0 90 0"), render("0 90"))
+
+	// Characters are rotated clockwise about their origin, so rotated stems extend below the
+	// baseline.
+	b := paintedBounds(render("90"), blue)
+	assert.Greater(t, b.Dx(), b.Dy())
+	assert.GreaterOrEqual(t, b.Min.Y, 60)
+}
+
+func TestTextPath(t *testing.T) {
+	render := func(defs, textPath string) image.Rectangle {
+		img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200">
+			<defs>`+defs+`</defs>
+			<text font-size="50" fill="blue">`+textPath+`</text>
+		</svg>`)
+		return paintedBounds(img, blue)
+	}
+
+	horizontal := `<path id="p" d="M0,100 H200" pathLength="100"/>`
+
+	t.Run("align", func(t *testing.T) {
+		// Glyphs on a downward path are rotated clockwise to follow it, so they extend to the
+		// right of the path.
+		b := render(`<path id="p" d="M100,0 V200"/>`, `<textPath href="#p">III</textPath>`)
+		require.False(t, b.Empty())
+		assert.Greater(t, b.Dy(), b.Dx())
+		assert.GreaterOrEqual(t, b.Min.X, 100)
+	})
+
+	t.Run("inline path", func(t *testing.T) {
+		assert.Equal(t, render(horizontal, `<textPath href="#p">II</textPath>`), render("", `<textPath path="M0,100 H200">II</textPath>`))
+	})
+
+	t.Run("start offset", func(t *testing.T) {
+		// Percentages refer to the length of the path, and lengths are scaled by pathLength.
+		b := render(horizontal, `<textPath href="#p" startOffset="50%">I</textPath>`)
+		assert.Equal(t, b, render(horizontal, `<textPath href="#p" startOffset="50">I</textPath>`))
+		assert.GreaterOrEqual(t, b.Min.X, 100)
+		assert.Less(t, b.Min.X, 120)
+	})
+
+	t.Run("off the end", func(t *testing.T) {
+		// Glyphs whose midpoints fall off the ends of the path are not rendered.
+		b := render(`<path id="p" d="M0,100 H60"/>`, `<textPath href="#p">IIIIIIIIII</textPath>`)
+		require.False(t, b.Empty())
+		assert.LessOrEqual(t, b.Max.X, 60)
+
+		b = render(`<path id="p" d="M0,100 H60"/>`, `<textPath href="#p" startOffset="-100">IIIIIIIIII</textPath>`)
+		require.False(t, b.Empty())
+		assert.GreaterOrEqual(t, b.Min.X, 0)
+	})
+
+	t.Run("side", func(t *testing.T) {
+		// Text on the right side of a path runs in the opposite direction and is upside down.
+		b := render(horizontal, `<textPath href="#p" side="right">I</textPath>`)
+		require.False(t, b.Empty())
+		assert.GreaterOrEqual(t, b.Min.Y, 100)
+		assert.Greater(t, b.Min.X, 180)
+	})
+
+	t.Run("stretch", func(t *testing.T) {
+		// Stretching glyphs along a straight path has no effect.
+		assert.Equal(t, render(horizontal, `<textPath href="#p">II</textPath>`), render(horizontal, `<textPath href="#p" method="stretch">II</textPath>`))
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		assert.True(t, render("", `<textPath href="#p">II</textPath>`).Empty())
+	})
+}
+
+func TestTextLength(t *testing.T) {
+	render := func(text string) image.Image {
+		return renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="100">`+text+`</svg>`)
+	}
+
+	t.Run("spacing", func(t *testing.T) {
+		// Extra space is distributed between the characters, which are not scaled.
+		img := render(`<text x="10" y="60" font-size="50" fill="blue" textLength="180">II</text>`)
+		b := paintedBounds(img, blue)
+		assert.Greater(t, b.Max.X, 170)
+		assert.LessOrEqual(t, b.Max.X, 190)
+
+		cols := columns(img, blue)
+		assert.True(t, anyIn(cols, 10, 30))
+		assert.False(t, anyIn(cols, 40, 160))
+
+		// A single character cannot be spaced.
+		assert.Equal(t, render(`<text x="10" y="60" font-size="50">I</text>`), render(`<text x="10" y="60" font-size="50" textLength="180">I</text>`))
+	})
+
+	t.Run("spacingAndGlyphs", func(t *testing.T) {
+		// Glyphs are stretched horizontally.
+		narrow := paintedBounds(render(`<text x="10" y="60" font-size="50" fill="blue">I</text>`), blue)
+		wide := paintedBounds(render(`<text x="10" y="60" font-size="50" fill="blue" textLength="150" lengthAdjust="spacingAndGlyphs">I</text>`), blue)
+		assert.Greater(t, wide.Dx(), 4*narrow.Dx())
+		assert.Equal(t, narrow.Dy(), wide.Dy())
+	})
+
+	t.Run("following characters", func(t *testing.T) {
+		// Characters after the adjusted element are shifted by the adjustment.
+		img := render(`<text x="10" y="60" font-size="50" fill="blue"><tspan textLength="120">II</tspan><tspan fill="red">I</tspan></text>`)
+		b := paintedBounds(img, red)
+		assert.GreaterOrEqual(t, b.Min.X, 130)
+		assert.Less(t, b.Min.X, 150)
+	})
+}
+
+func TestTextBaselines(t *testing.T) {
+	render := func(text string) image.Rectangle {
+		img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200">`+text+`</svg>`)
+		return paintedBounds(img, blue)
+	}
+	baseline := func(attrs string) image.Rectangle {
+		return render(`<text x="10" y="100" font-size="50" fill="blue" ` + attrs + `>I</text>`)
+	}
+
+	t.Run("dominant-baseline", func(t *testing.T) {
+		alphabetic := baseline("")
+		assert.Equal(t, alphabetic, baseline(`dominant-baseline="alphabetic"`))
+		assert.LessOrEqual(t, alphabetic.Max.Y, 100)
+
+		// Each baseline lies further above the alphabetic baseline than the last, so the glyph
+		// moves further down.
+		previous := alphabetic
+		for _, name := range []string{"middle", "central", "mathematical", "hanging", "text-before-edge"} {
+			b := baseline(`dominant-baseline="` + name + `"`)
+			assert.Greater(t, b.Min.Y, previous.Min.Y, name)
+			previous = b
+		}
+
+		// The glyph hangs from the top of the em box.
+		assert.GreaterOrEqual(t, previous.Min.Y, 100)
+
+		// The bottom of the em box lies below the alphabetic baseline.
+		b := baseline(`dominant-baseline="ideographic"`)
+		assert.Less(t, b.Max.Y, alphabetic.Max.Y)
+		assert.Equal(t, b, baseline(`dominant-baseline="text-after-edge"`))
+	})
+
+	t.Run("alignment-baseline", func(t *testing.T) {
+		aligned := func(attrs string) image.Rectangle {
+			return render(`<text x="10" y="100" font-size="50">I<tspan fill="blue" ` + attrs + `>I</tspan></text>`)
+		}
+		unaligned := aligned("")
+
+		// The tspan's hanging baseline is aligned with the hanging baseline of its parent, so a
+		// tspan in the same font does not move.
+		assert.Equal(t, unaligned, aligned(`alignment-baseline="hanging"`))
+		assert.Equal(t, unaligned, aligned(`alignment-baseline="ideographic"`))
+
+		// The baselines of a smaller font lie closer to its alphabetic baseline, so a smaller
+		// tspan moves up to align its hanging baseline and down to align its ideographic
+		// baseline.
+		small := aligned(`font-size="25"`)
+		assert.Less(t, aligned(`font-size="25" alignment-baseline="hanging"`).Min.Y, small.Min.Y)
+		assert.Greater(t, aligned(`font-size="25" alignment-baseline="ideographic"`).Min.Y, small.Min.Y)
+
+		// The text element's own baseline is aligned with the current text position.
+		hanging := render(`<text x="10" y="100" font-size="50" fill="blue" dominant-baseline="hanging"><tspan alignment-baseline="hanging">I</tspan></text>`)
+		assert.Equal(t, baseline(`dominant-baseline="hanging"`), hanging)
+
+		// alignment-baseline is not inherited.
+		assert.Equal(t, baseline(""), render(`<text x="10" y="100" font-size="50" fill="blue" alignment-baseline="hanging"><tspan>I</tspan></text>`))
+	})
+
+	t.Run("baseline-shift", func(t *testing.T) {
+		shifted := func(shift string) image.Rectangle {
+			return render(`<text x="10" y="100" font-size="50">I<tspan fill="blue" baseline-shift="` + shift + `">I</tspan>I</text>`)
+		}
+		unshifted := shifted("baseline")
+
+		assert.Equal(t, unshifted.Add(image.Pt(0, -10)), shifted("10"))
+		assert.Equal(t, unshifted.Add(image.Pt(0, -10)), shifted("20%"))
+		assert.Less(t, shifted("super").Min.Y, unshifted.Min.Y)
+		assert.Greater(t, shifted("sub").Min.Y, unshifted.Min.Y)
+
+		// Shifts accumulate through nested elements but do not affect the following text.
+		img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200">
+			<text x="10" y="100" font-size="50" fill="red">I<tspan baseline-shift="10"><tspan fill="blue" baseline-shift="10">I</tspan></tspan>I</text>
+		</svg>`)
+		assert.Equal(t, unshifted.Add(image.Pt(0, -20)), paintedBounds(img, blue))
+		assert.Equal(t, baseline("").Min.Y, paintedBounds(img, red).Min.Y)
+	})
+}
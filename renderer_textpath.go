@@ -0,0 +1,207 @@
+package svg
+
+import (
+	"math"
+	"sort"
+
+	"github.com/fogleman/gg"
+)
+
+// pathSegment is a line segment of a flattened path. start is the distance along the path to
+// the start of the segment.
+type pathSegment struct {
+	x0, y0, x1, y1 float64
+	start, length  float64
+}
+
+// pathSampler is a pathBuilder that flattens a path into line segments so that points can be
+// found at given distances along it. Points are transformed by matrix as they are added. Moves
+// between subpaths do not contribute to the length of the path.
+type pathSampler struct {
+	matrix   gg.Matrix
+	segments []pathSegment
+	length   float64
+
+	// (x, y) is the current point and (sx, sy) is the start of the current subpath, both
+	// before transformation.
+	x, y, sx, sy float64
+}
+
+func (p *pathSampler) add(x, y float64) {
+	x0, y0 := p.matrix.TransformPoint(p.x, p.y)
+	x1, y1 := p.matrix.TransformPoint(x, y)
+	if l := math.Hypot(x1-x0, y1-y0); l > 0 {
+		p.segments = append(p.segments, pathSegment{x0: x0, y0: y0, x1: x1, y1: y1, start: p.length, length: l})
+		p.length += l
+	}
+	p.x, p.y = x, y
+}
+
+// flatten adds a curve defined over [0, 1] to the path as a sequence of line segments.
+func (p *pathSampler) flatten(at func(t float64) (float64, float64)) {
+	const segments = 32
+	for i := 1; i <= segments; i++ {
+		p.add(at(float64(i) / segments))
+	}
+}
+
+func (p *pathSampler) MoveTo(x, y float64) {
+	p.x, p.y, p.sx, p.sy = x, y, x, y
+}
+
+func (p *pathSampler) LineTo(x, y float64) {
+	p.add(x, y)
+}
+
+func (p *pathSampler) QuadraticTo(x1, y1, x2, y2 float64) {
+	x0, y0 := p.x, p.y
+	p.flatten(func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*x0 + 2*mt*t*x1 + t*t*x2, mt*mt*y0 + 2*mt*t*y1 + t*t*y2
+	})
+}
+
+func (p *pathSampler) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	x0, y0 := p.x, p.y
+	p.flatten(func(t float64) (float64, float64) {
+		mt := 1 - t
+		return mt*mt*mt*x0 + 3*mt*mt*t*x1 + 3*mt*t*t*x2 + t*t*t*x3, mt*mt*mt*y0 + 3*mt*mt*t*y1 + 3*mt*t*t*y2 + t*t*t*y3
+	})
+}
+
+func (p *pathSampler) ClosePath() {
+	p.add(p.sx, p.sy)
+}
+
+func (p *pathSampler) NewSubPath() {}
+
+// reverse reverses the direction of the path.
+func (p *pathSampler) reverse() {
+	reversed := make([]pathSegment, len(p.segments))
+	start := 0.0
+	for i := range p.segments {
+		s := p.segments[len(p.segments)-1-i]
+		reversed[i] = pathSegment{x0: s.x1, y0: s.y1, x1: s.x0, y1: s.y0, start: start, length: s.length}
+		start += s.length
+	}
+	p.segments = reversed
+}
+
+// at returns the point at the given distance along the path and the angle of the path's
+// tangent at that point. Distances beyond the ends of the path are extrapolated along the
+// first or last segment. The path must not be empty.
+func (p *pathSampler) at(d float64) (x, y, angle float64) {
+	i := sort.Search(len(p.segments), func(i int) bool {
+		s := &p.segments[i]
+		return s.start+s.length >= d
+	})
+	if i == len(p.segments) {
+		i--
+	}
+
+	s := &p.segments[i]
+	t := (d - s.start) / s.length
+	return s.x0 + (s.x1-s.x0)*t, s.y0 + (s.y1-s.y0)*t, math.Atan2(s.y1-s.y0, s.x1-s.x0)
+}
+
+// textPathLayout describes the path along which the contents of a textPath element are laid
+// out. If path is nil, the textPath element does not reference a valid path and its contents
+// are not rendered.
+type textPathLayout struct {
+	path *pathSampler
+
+	// offset is the distance along the path at which the text begins.
+	offset float64
+
+	// stretch is true if glyphs are warped along the path rather than rotated to align with
+	// it.
+	stretch bool
+}
+
+// computeTextPath computes the layout path of the textPath element at the top of the stack.
+// The path is given by the element's path attribute, if present, and otherwise by the path
+// element that it references. Paths are expressed in the user space of the text element.
+//
+// Both values of the spacing attribute use exact spacing.
+func (r *renderer) computeTextPath(e *TextPath) *textPathLayout {
+	l := &textPathLayout{stretch: e.Method == "stretch"}
+
+	path := &pathSampler{matrix: gg.Identity()}
+	pathLength := 0.0
+	switch {
+	case len(e.Path.Commands) != 0:
+		tracePath(path, e.Path.Commands)
+	default:
+		target, _ := r.lookup(e.Href)
+		p, ok := target.(*Path)
+		if !ok {
+			return l
+		}
+		path.matrix = p.Transform.Matrix()
+		tracePath(path, p.D.Commands)
+		pathLength = p.PathLength
+	}
+	if len(path.segments) == 0 {
+		return l
+	}
+
+	if e.Side == "right" {
+		path.reverse()
+	}
+	l.path = path
+
+	// Percentages refer to the length of the path. Lengths are scaled by the ratio of the
+	// path's actual length to its author-specified length, if any.
+	l.offset = r.computeLengthPercentage(path.length, e.StartOffset)
+	if e.StartOffset.Percentage == 0 && pathLength > 0 {
+		l.offset *= path.length / pathLength
+	}
+	return l
+}
+
+// placeOnPath positions the glyphs laid out along a text path. Each glyph is centered on the
+// point on the path at the midpoint of its advance and rotated to follow the path's tangent.
+// Glyphs whose midpoints fall off either end of the path are hidden.
+func placeOnPath(glyphs []textGlyph) {
+	for i := range glyphs {
+		g := &glyphs[i]
+		p := g.path
+		if p == nil {
+			continue
+		}
+		if p.path == nil {
+			g.hidden = true
+			continue
+		}
+
+		mid := g.px + g.advance/2 + p.offset
+		if mid < 0 || mid > p.path.length {
+			g.hidden = true
+			continue
+		}
+		if p.stretch {
+			// Stretched glyphs are mapped onto the path point by point by glyphTransform.
+			continue
+		}
+
+		x, y, angle := p.path.at(mid)
+		sin, cos := math.Sincos(angle)
+		g.px, g.py = x-cos*g.advance/2-sin*g.py, y-sin*g.advance/2+cos*g.py
+		g.angle += angle
+	}
+}
+
+// warp returns a function that maps points in the coordinate space of a stretched glyph onto
+// the path. Each point is positioned at the distance along the path given by its x coordinate
+// and offset perpendicular to the path by its y coordinate.
+func (p *textPathLayout) warp(g *textGlyph) func(x, y float64) (float64, float64) {
+	start, offset := g.px+p.offset, g.py
+	sin, cos := math.Sincos(g.angle)
+	return func(x, y float64) (float64, float64) {
+		x, y = x*cos-y*sin, x*sin+y*cos
+
+		px, py, angle := p.path.at(start + x)
+		s, c := math.Sincos(angle)
+		return px - s*(offset+y), py + c*(offset+y)
+	}
+}
@@ -0,0 +1,180 @@
+package svg
+
+import "math"
+
+// DefaultDPI is the resolution used to convert absolute lengths into user units if none is
+// specified. It matches the CSS reference pixel, which is 1/96th of an inch.
+const DefaultDPI = 96
+
+// mediumFontSize is the font size that corresponds to the medium font size keyword.
+const mediumFontSize = 16
+
+// absoluteSizes maps the absolute font size keywords to their scale relative to medium.
+var absoluteSizes = map[string]float64{
+	"xx-small":  3.0 / 5.0,
+	"x-small":   3.0 / 4.0,
+	"small":     8.0 / 9.0,
+	"medium":    1,
+	"large":     6.0 / 5.0,
+	"x-large":   3.0 / 2.0,
+	"xx-large":  2,
+	"xxx-large": 3,
+}
+
+// absoluteLength converts a length in absolute units into user units at the given resolution.
+// Unitless lengths and pixels are user units. If the length's units are not absolute, the
+// second result is false.
+func absoluteLength(l Length, dpi float64) (float64, bool) {
+	switch l.Units {
+	case "", "px":
+		return l.Value, true
+	case "in":
+		return l.Value * dpi, true
+	case "cm":
+		return l.Value * dpi / 2.54, true
+	case "mm":
+		return l.Value * dpi / 25.4, true
+	case "q":
+		return l.Value * dpi / 101.6, true
+	case "pt":
+		return l.Value * dpi / 72, true
+	case "pc":
+		return l.Value * dpi / 6, true
+	default:
+		return 0, false
+	}
+}
+
+// computeLength converts a length into user units. Font-relative units are resolved against
+// the font of the element at the top of the stack, and viewport-relative units are resolved
+// against the viewport established by the outermost svg element.
+func (r *renderer) computeLength(l Length) float64 {
+	if v, ok := absoluteLength(l, r.dpi); ok {
+		return v
+	}
+
+	switch l.Units {
+	case "em":
+		return l.Value * r.computeFontSize()
+	case "ex", "cap", "ch", "ic":
+		return l.Value * r.fontUnit(l.Units)
+	case "lh":
+		return l.Value * r.computeFontSize() * normalLineHeight
+	case "rem", "rex", "rcap", "rch", "ric", "rlh":
+		// Root-relative units on the root element are resolved against the initial font.
+		if len(r.stack) <= 2 {
+			return r.computeLength(Length{Value: l.Value, Units: l.Units[1:]})
+		}
+		stack := r.stack
+		r.stack = stack[:2]
+		defer func() { r.stack = stack }()
+		return r.computeLength(Length{Value: l.Value, Units: l.Units[1:]})
+	}
+
+	viewport := r.top()
+	if len(r.stack) > 1 {
+		viewport = r.stack[1]
+	}
+	switch l.Units {
+	case "vw", "vi":
+		return l.Value * viewport.width / 100
+	case "vh", "vb":
+		return l.Value * viewport.height / 100
+	case "vmin":
+		return l.Value * math.Min(viewport.width, viewport.height) / 100
+	case "vmax":
+		return l.Value * math.Max(viewport.width, viewport.height) / 100
+	default:
+		// The grammar only admits known units, so this should be unreachable.
+		return l.Value
+	}
+}
+
+// normalLineHeight is the ratio of the used line height to the font size for line-height:
+// normal.
+const normalLineHeight = 1.2
+
+// fontUnit returns the size of one of the given font-relative unit (ex, cap, ch, or ic) for
+// the font of the element at the top of the stack. If the size cannot be determined from the
+// font, it is approximated from the font size as recommended by CSS.
+func (r *renderer) fontUnit(units string) float64 {
+	size := r.computeFontSize()
+
+	face, err := r.fontFace()
+	if err == nil {
+		defer face.Close()
+
+		metrics := face.Metrics()
+		switch units {
+		case "ex":
+			if metrics.XHeight > 0 {
+				return float64(metrics.XHeight) / 64
+			}
+		case "cap":
+			if metrics.CapHeight > 0 {
+				return float64(metrics.CapHeight) / 64
+			}
+			return float64(metrics.Ascent) / 64
+		case "ch":
+			if advance, ok := face.GlyphAdvance('0'); ok {
+				return float64(advance) / 64
+			}
+		case "ic":
+			if advance, ok := face.GlyphAdvance('水'); ok {
+				return float64(advance) / 64
+			}
+		}
+	}
+
+	if units == "ex" || units == "ch" {
+		return size / 2
+	}
+	return size
+}
+
+// computeFontSize returns the computed font size of the element at the top of the stack in
+// user units.
+func (r *renderer) computeFontSize() float64 {
+	i := len(r.stack) - 1
+	for i >= 0 && r.stack[i].attrs().FontSize == nil {
+		i--
+	}
+	if i < 0 {
+		return mediumFontSize
+	}
+	fs := r.stack[i].attrs().FontSize
+
+	// Relative font sizes are resolved against the font size of the element's parent.
+	stack := r.stack
+	r.stack = stack[:i]
+	defer func() { r.stack = stack }()
+
+	parent := func() float64 {
+		if i == 0 {
+			return mediumFontSize
+		}
+		return r.computeFontSize()
+	}
+
+	switch {
+	case fs.Ident == "larger":
+		return parent() * 1.2
+	case fs.Ident == "smaller":
+		return parent() / 1.2
+	case fs.Ident != "":
+		if scale, ok := absoluteSizes[fs.Ident]; ok {
+			return scale * mediumFontSize
+		}
+		return parent()
+	case fs.Percentage != 0:
+		return fs.Percentage * parent()
+	case fs.Number != 0:
+		return fs.Number
+	case i == 0:
+		v, _ := absoluteLength(fs.Length, r.dpi)
+		return v
+	default:
+		// Font-relative units in font-size refer to the parent's font.
+		return r.computeLength(fs.Length)
+	}
+}
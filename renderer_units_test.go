@@ -0,0 +1,129 @@
+package svg
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultFontSize(t *testing.T) {
+	// The document size and its contents resolve font-relative units against the same initial
+	// font size of 16 pixels.
+	img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="2em" height="2rem">
+		<rect width="1em" height="1rem" fill="red"/>
+	</svg>`)
+	assert.Equal(t, image.Rect(0, 0, 32, 32), img.Bounds())
+	assert.Equal(t, image.Rect(0, 0, 16, 16), paintedBounds(img, red))
+
+	// So does the medium keyword.
+	img = renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="32" height="32" font-size="medium">
+		<rect width="1em" height="1em" fill="red"/>
+	</svg>`)
+	assert.Equal(t, image.Rect(0, 0, 16, 16), paintedBounds(img, red))
+}
+
+func TestLengthUnits(t *testing.T) {
+	// The expected widths count only fully covered pixels at 96 DPI, so fractional widths round
+	// down.
+	cases := []struct {
+		width    string
+		expected int
+	}{
+		{"10", 10},
+		{"10px", 10},
+		{"0.25in", 24},
+		{"18pt", 24},
+		{"2pc", 32},
+		{"1cm", 37},
+		{"4mm", 15},
+		{"40Q", 37},
+		{"1.5em", 24},
+		{"50%", 20},
+	}
+	for _, c := range cases {
+		t.Run(c.width, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="40" height="40">
+				<rect width="`+c.width+`" height="10" fill="red"/>
+			</svg>`)
+			assert.Equal(t, c.expected, paintedBounds(img, red).Dx())
+		})
+	}
+}
+
+func TestFontRelativeUnits(t *testing.T) {
+	// The default font is Go Regular, whose x-height is about 0.53em and whose zero is about
+	// 0.56em wide.
+	cases := []struct {
+		attrs, width string
+		expected     int
+	}{
+		{``, "1em", 16},
+		{`font-size="32"`, "1em", 32},
+		{`font-size="32"`, "1rem", 16},
+		{`font-size="2em"`, "1.5em", 48},
+		{``, "10ex", 84},
+		{`font-size="32"`, "10ex", 169},
+		{`font-size="32"`, "1rex", 8},
+		{``, "10ch", 89},
+		{`font-size="32"`, "10ch", 177},
+	}
+	for _, c := range cases {
+		t.Run(c.attrs+" "+c.width, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="40">
+				<g `+c.attrs+`><rect width="`+c.width+`" height="10" fill="red"/></g>
+			</svg>`)
+			assert.Equal(t, c.expected, paintedBounds(img, red).Dx())
+		})
+	}
+}
+
+func TestViewportUnits(t *testing.T) {
+	cases := []struct {
+		width    string
+		expected int
+	}{
+		{"10vw", 8},
+		{"10vh", 4},
+		{"50vmin", 20},
+		{"50vmax", 40},
+	}
+	for _, c := range cases {
+		t.Run(c.width, func(t *testing.T) {
+			img := renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="80" height="40">
+				<rect width="`+c.width+`" height="10" fill="red"/>
+			</svg>`)
+			assert.Equal(t, c.expected, paintedBounds(img, red).Dx())
+		})
+	}
+}
+
+func TestDPI(t *testing.T) {
+	// Absolute lengths are converted to user units at the configured resolution.
+	opts := &Options{DPI: 72}
+	cases := []struct {
+		width    string
+		expected int
+	}{
+		{"1in", 72},
+		{"36pt", 36},
+		{"2.54cm", 72},
+		{"10px", 10},
+		{"1em", 16},
+	}
+	for _, c := range cases {
+		t.Run(c.width, func(t *testing.T) {
+			img := renderStringWithOptions(t, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="40">
+				<rect width="`+c.width+`" height="10" fill="red"/>
+			</svg>`, opts)
+			assert.Equal(t, c.expected, paintedBounds(img, red).Dx())
+		})
+	}
+
+	// So is the size of the document.
+	img := renderStringWithOptions(t, `<svg xmlns="http://www.w3.org/2000/svg" width="1in" height="0.5in"/>`, &Options{DPI: 144})
+	assert.Equal(t, image.Rect(0, 0, 144, 72), img.Bounds())
+
+	img = renderString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="1in" height="0.5in"/>`)
+	assert.Equal(t, image.Rect(0, 0, 96, 48), img.Bounds())
+}
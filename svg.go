@@ -4,9 +4,18 @@ import "encoding/xml"
 
 // SVG represents an SVG document.
 type SVG struct {
+	ElementAttributes
+
 	XMLName xml.Name `xml:"svg"`
 
-	Style *Style `xml:"style"`
+	// Style is the document's first top-level style element, if any. Style elements are also
+	// present in Children. The svg element's style attribute is ElementAttributes.Style.
+	//
+	// Deprecated: Style ignores all but the first style element. Use Children instead.
+	Style *Style `xml:"-"`
+
+	PreserveAspectRatio PreserveAspectRatio `xml:"preserveAspectRatio,attr"`
+	ViewBox             *ViewBox            `xml:"viewBox,attr"`
 
 	X      LengthPercentage    `xml:"x,attr"`
 	Y      LengthPercentage    `xml:"y,attr"`
@@ -16,14 +25,39 @@ type SVG struct {
 	Children []any `xml:",any"`
 }
 
+func (svg *SVG) UnmarshalXML(d *xml.Decoder, s xml.StartElement) error {
+	valid := s
+	valid.Attr = validProperties(s.Attr)
+
+	type plain SVG
+	if err := d.DecodeElement((*plain)(svg), &valid); err != nil {
+		return err
+	}
+	svg.attributes = s.Attr
+
+	for _, c := range svg.Children {
+		if style, ok := c.X.(*Style); ok {
+			svg.Style = style
+			break
+		}
+	}
+	return nil
+}
+
+func (SVG) isElement() {}
+
 // Style represents an SVG `style` element.
 type Style struct {
+	ElementAttributes
+
 	XMLName xml.Name `xml:"style"`
 
 	Type  string `xml:"type,attr"`
 	Media string `xml:"media,attr"`
-	Title string `xml:"title,attr`
+	Title string `xml:"title,attr"`
 
+	// Style holds the contents of the style sheet. The element's style attribute is
+	// ElementAttributes.Style.
 	Style string `xml:",chardata"`
 }
 
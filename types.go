@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"image/color"
+	"math"
 	"strconv"
 	"strings"
 
@@ -19,32 +20,37 @@ const (
 type Ident string
 
 func (i *Ident) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<custom-ident>+", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
-	if tokens[0].Type != css.IdentToken {
-		return errors.New("expected an identifier")
+	idents := make([]string, len(tokens))
+	for i, t := range tokens {
+		idents[i] = t.Value
 	}
-
-	*i = Ident(tokens[0].Value)
+	*i = Ident(strings.Join(idents, " "))
 	return nil
 }
 
 type Units string
 
 func (u *Units) UnmarshalText(text []byte) error {
-	switch Units(text) {
-	case UserSpaceOnUse, ObjectBoundingBox:
-		*u = Units(text)
-		return nil
-	default:
-		return errors.New("units must be one of \"userSpaceOnUse\" or \"objectBoundingBox\"")
+	tokens, err := matchTokens("userSpaceOnUse | objectBoundingBox", text)
+	if err != nil {
+		return err
+	}
+	*u = Units(tokens[0].Value)
+	return nil
+}
+
+// parseURL returns the URL referenced by a url token, e.g. `url(#gradient)`.
+func parseURL(token cssToken) string {
+	url := strings.TrimSpace(token.Value[len("url(") : len(token.Value)-1])
+	if len(url) >= 2 && (url[0] == '"' || url[0] == '\'') {
+		url = url[1 : len(url)-1]
 	}
+	return url
 }
 
 type URLIdent struct {
@@ -53,25 +59,32 @@ type URLIdent struct {
 }
 
 func (ui *URLIdent) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<url> | <custom-ident>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	token := tokens[0]
-	switch token.Type {
-	case css.URLToken:
-		ui.URL = token.Value[len("url(") : len(token.Value)-1]
-		return nil
-	case css.IdentToken:
+	if token.Type == css.URLToken {
+		ui.URL = parseURL(token)
+	} else {
 		ui.Ident = token.Value
-		return nil
-	default:
-		return errors.New("expected a URL or identifier")
 	}
+	return nil
+}
+
+// parseNumber parses a number token.
+func parseNumber(token cssToken) (float64, error) {
+	return strconv.ParseFloat(token.Value, 64)
+}
+
+// parsePercentage parses a percentage token. The result is scaled to the range [0, 1].
+func parsePercentage(token cssToken) (float64, error) {
+	p, err := strconv.ParseFloat(token.Value[:len(token.Value)-1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return p / 100, nil
 }
 
 // NumberPercentage represents an XML number or percentage.
@@ -81,33 +94,18 @@ type NumberPercentage struct {
 }
 
 func (np *NumberPercentage) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<number> | <percentage>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	token := tokens[0]
-	switch token.Type {
-	case css.NumberToken:
-		n, err := strconv.ParseFloat(token.Value, 64)
-		if err != nil {
-			return err
-		}
-		np.Number = n
-		return nil
-	case css.PercentageToken:
-		p, err := strconv.ParseFloat(token.Value[:len(token.Value)-1], 64)
-		if err != nil {
-			return err
-		}
-		np.Percentage = p / 100
-		return nil
-	default:
-		return errors.New("expected a number or percentage")
+	if token.Type == css.NumberToken {
+		np.Number, err = parseNumber(token)
+	} else {
+		np.Percentage, err = parsePercentage(token)
 	}
+	return err
 }
 
 // NumberIdent represents an XML number or identifier.
@@ -117,29 +115,18 @@ type NumberIdent struct {
 }
 
 func (ni *NumberIdent) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<number> | <custom-ident>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	token := tokens[0]
-	switch token.Type {
-	case css.NumberToken:
-		n, err := strconv.ParseFloat(token.Value, 64)
-		if err != nil {
-			return err
-		}
-		ni.Number = n
-		return nil
-	case css.IdentToken:
+	if token.Type == css.NumberToken {
+		ni.Number, err = parseNumber(token)
+	} else {
 		ni.Ident = token.Value
-		return nil
-	default:
-		return errors.New("expected a number or identifier")
 	}
+	return err
 }
 
 // Length represents a CSS length.
@@ -148,44 +135,57 @@ type Length struct {
 	Units string
 }
 
-func parseLength(token cssToken) (Length, error) {
-	switch token.Type {
-	case css.NumberToken:
-		if token.Value == "0" {
-			return Length{}, nil
+// splitDimension splits a number or dimension token into its numeric value and its units.
+func splitDimension(token cssToken) (float64, string, error) {
+	// The numeric part of the token is its longest prefix that is a valid number.
+	v := token.Value
+	for i := len(v); i > 0; i-- {
+		if n, err := strconv.ParseFloat(v[:i], 64); err == nil {
+			return n, v[i:], nil
 		}
-	case css.DimensionToken:
-		// OK
-	default:
-		return Length{}, errors.New("expceted a dimension")
 	}
+	return 0, "", fmt.Errorf("invalid number %v", v)
+}
 
-	// Snip off the units
-	v, units := token.Value, ""
-	for i := len(v) - 1; i >= 0; i-- {
-		c := v[i]
-		if c >= '0' && c <= '9' {
-			v, units = v[:i+1], v[i+1:]
-			break
+func parseLength(token cssToken) (Length, error) {
+	switch token.Type {
+	case css.NumberToken, css.DimensionToken:
+		n, units, err := splitDimension(token)
+		if err != nil {
+			return Length{}, err
 		}
+		return Length{Value: n, Units: strings.ToLower(units)}, nil
+	default:
+		return Length{}, errors.New("expected a length")
 	}
+}
 
-	n, err := strconv.ParseFloat(v, 64)
+// parseAngle parses an angle token. The result is given in degrees. Unitless numbers are
+// interpreted as degrees.
+func parseAngle(token cssToken) (float64, error) {
+	n, units, err := splitDimension(token)
 	if err != nil {
-		return Length{}, err
+		return 0, err
+	}
+	switch strings.ToLower(units) {
+	case "", "deg":
+		return n, nil
+	case "rad":
+		return n * 180 / math.Pi, nil
+	case "grad":
+		return n * 360 / 400, nil
+	case "turn":
+		return n * 360, nil
+	default:
+		return 0, fmt.Errorf("unsupported units %v", units)
 	}
-
-	return Length{Value: n, Units: units}, nil
 }
 
 func (l *Length) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length> | <number>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	*l, err = parseLength(tokens[0])
 	return err
@@ -197,29 +197,18 @@ type LengthIdent struct {
 }
 
 func (lp *LengthIdent) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length> | <number> | <custom-ident>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	token := tokens[0]
-	switch token.Type {
-	case css.NumberToken, css.DimensionToken:
-		l, err := parseLength(token)
-		if err != nil {
-			return err
-		}
-		lp.Length = l
-		return nil
-	case css.IdentToken:
+	if token.Type == css.IdentToken {
 		lp.Ident = token.Value
 		return nil
-	default:
-		return errors.New("expected a length or identifier")
 	}
+	lp.Length, err = parseLength(token)
+	return err
 }
 
 type LengthPercentage struct {
@@ -228,32 +217,26 @@ type LengthPercentage struct {
 }
 
 func parseLengthPercentage(token cssToken) (LengthPercentage, error) {
-	switch token.Type {
-	case css.NumberToken, css.DimensionToken:
-		l, err := parseLength(token)
-		if err != nil {
-			return LengthPercentage{}, err
-		}
-		return LengthPercentage{Length: l}, nil
-	case css.PercentageToken:
-		p, err := strconv.ParseFloat(token.Value[:len(token.Value)-1], 64)
+	if token.Type == css.PercentageToken {
+		p, err := parsePercentage(token)
 		if err != nil {
 			return LengthPercentage{}, err
 		}
-		return LengthPercentage{Percentage: p / 100}, nil
-	default:
-		return LengthPercentage{}, errors.New("expected a length or percentage")
+		return LengthPercentage{Percentage: p}, nil
+	}
+
+	l, err := parseLength(token)
+	if err != nil {
+		return LengthPercentage{}, err
 	}
+	return LengthPercentage{Length: l}, nil
 }
 
 func (lp *LengthPercentage) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length-percentage>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	*lp, err = parseLengthPercentage(tokens[0])
 	return err
@@ -266,34 +249,18 @@ type BoxLengthPercentage struct {
 }
 
 func (blp *BoxLengthPercentage) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length-percentage> | auto | inherit", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	token := tokens[0]
-	switch token.Type {
-	case css.IdentToken:
-		switch token.Value {
-		case "auto", "inherit":
-			blp.Value = token.Value
-		default:
-			return errors.New("expected 'auto' or 'inherit'")
-		}
-		return nil
-	case css.NumberToken, css.DimensionToken, css.PercentageToken:
-		lp, err := parseLengthPercentage(token)
-		if err != nil {
-			return err
-		}
-		blp.LengthPercentage = lp
+	if token.Type == css.IdentToken {
+		blp.Value = token.Value
 		return nil
-	default:
-		return errors.New("expected a length, percentage, 'auto', or 'inherit'")
 	}
+	blp.LengthPercentage, err = parseLengthPercentage(token)
+	return err
 }
 
 type LengthPercentageNumber struct {
@@ -303,32 +270,26 @@ type LengthPercentageNumber struct {
 }
 
 func parseLengthPercentageNumber(token cssToken) (LengthPercentageNumber, error) {
-	switch token.Type {
-	case css.NumberToken:
-		n, err := strconv.ParseFloat(token.Value, 64)
+	if token.Type == css.NumberToken {
+		n, err := parseNumber(token)
 		if err != nil {
 			return LengthPercentageNumber{}, err
 		}
 		return LengthPercentageNumber{Number: n}, nil
-	case css.DimensionToken, css.PercentageToken:
-		lp, err := parseLengthPercentage(token)
-		if err != nil {
-			return LengthPercentageNumber{}, err
-		}
-		return LengthPercentageNumber{LengthPercentage: lp}, nil
-	default:
-		return LengthPercentageNumber{}, errors.New("expected a length, percentage, or number")
 	}
+
+	lp, err := parseLengthPercentage(token)
+	if err != nil {
+		return LengthPercentageNumber{}, err
+	}
+	return LengthPercentageNumber{LengthPercentage: lp}, nil
 }
 
 func (lpn *LengthPercentageNumber) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length-percentage>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	*lpn, err = parseLengthPercentageNumber(tokens[0])
 	return err
@@ -339,22 +300,50 @@ type LengthPercentageNumbers struct {
 }
 
 func (ns *LengthPercentageNumbers) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("[ <length-percentage> [ ,? <length-percentage> ]* ]?", text)
 	if err != nil {
 		return err
 	}
 
 	var values []LengthPercentageNumber
-	for len(tokens) > 0 {
-		v, err := parseLengthPercentageNumber(tokens[0])
+	for _, token := range tokens {
+		if token.Type == css.CommaToken {
+			continue
+		}
+
+		v, err := parseLengthPercentageNumber(token)
 		if err != nil {
 			return err
 		}
-		values, tokens = append(values, v), tokens[1:]
+		values = append(values, v)
+	}
+
+	ns.Values = values
+	return nil
+}
+
+// Numbers represents a list of numbers separated by whitespace and/or commas.
+type Numbers struct {
+	Values []float64
+}
 
-		if len(tokens) > 0 && tokens[0].Type == css.CommaToken {
-			tokens = tokens[1:]
+func (ns *Numbers) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("[ <number> [ ,? <number> ]* ]?", text)
+	if err != nil {
+		return err
+	}
+
+	var values []float64
+	for _, token := range tokens {
+		if token.Type == css.CommaToken {
+			continue
 		}
+
+		v, err := parseNumber(token)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
 	}
 
 	ns.Values = values
@@ -367,31 +356,18 @@ type LengthPercentageIdent struct {
 	Ident string
 }
 
-func parseLengthPercentageIdent(token cssToken) (LengthPercentageIdent, error) {
-	switch token.Type {
-	case css.IdentToken:
-		return LengthPercentageIdent{Ident: token.Value}, nil
-	case css.DimensionToken, css.PercentageToken:
-		lp, err := parseLengthPercentage(token)
-		if err != nil {
-			return LengthPercentageIdent{}, err
-		}
-		return LengthPercentageIdent{LengthPercentage: lp}, nil
-	default:
-		return LengthPercentageIdent{}, errors.New("expected a length, percentage, or identifier")
-	}
-}
-
 func (lpi *LengthPercentageIdent) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length-percentage> | <custom-ident>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
-	*lpi, err = parseLengthPercentageIdent(tokens[0])
+	token := tokens[0]
+	if token.Type == css.IdentToken {
+		lpi.Ident = token.Value
+		return nil
+	}
+	lpi.LengthPercentage, err = parseLengthPercentage(token)
 	return err
 }
 
@@ -402,29 +378,18 @@ type LengthPercentageNumberIdent struct {
 }
 
 func (lpni *LengthPercentageNumberIdent) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<length-percentage> | <custom-ident>", text)
 	if err != nil {
 		return err
 	}
-	if len(tokens) != 1 {
-		return errors.New("unexpected token")
-	}
 
 	token := tokens[0]
-	switch token.Type {
-	case css.IdentToken:
+	if token.Type == css.IdentToken {
 		lpni.Ident = token.Value
 		return nil
-	case css.NumberToken, css.DimensionToken, css.PercentageToken:
-		lpn, err := parseLengthPercentageNumber(token)
-		if err != nil {
-			return err
-		}
-		lpni.LengthPercentageNumber = lpn
-		return nil
-	default:
-		return errors.New("expected a length, percentage, number, or identifier")
 	}
+	lpni.LengthPercentageNumber, err = parseLengthPercentageNumber(token)
+	return err
 }
 
 // Color represents a color.
@@ -432,91 +397,100 @@ type Color struct {
 	Value color.Color
 }
 
-func parseColorFunction(tokens []cssToken) (color.Color, error) {
-	fn, arity := tokens[0].Value, 0
-	switch fn {
-	case "rgb(", "hsl(":
-		arity = 3
-	case "rgba(", "hsla(":
-		arity = 4
-	default:
-		return nil, fmt.Errorf("unknown color function %v", tokens[0].Value)
+// parseColorComponent parses an RGB color component. Numbers are in the range [0, 255].
+func parseColorComponent(token cssToken) (float64, error) {
+	if token.Type == css.PercentageToken {
+		p, err := parsePercentage(token)
+		return p * 255, err
 	}
+	return parseNumber(token)
+}
 
-	tokens = tokens[1:]
-	if len(tokens) == 0 {
-		return nil, errors.New("expected a number or ')'")
+// parseAlphaValue parses an alpha value. Numbers are in the range [0, 1].
+func parseAlphaValue(token cssToken) (float64, error) {
+	if token.Type == css.PercentageToken {
+		return parsePercentage(token)
 	}
+	return parseNumber(token)
+}
 
-	args := make([]byte, 0, arity)
-	if tokens[0].Type == css.RightParenthesisToken {
-		tokens = tokens[1:]
-	} else {
-		for {
-			switch tokens[0].Type {
-			case css.NumberToken:
-				n, err := strconv.ParseUint(tokens[0].Value, 10, 8)
-				if err != nil {
-					return nil, err
-				}
-				args, tokens = append(args, byte(n)), tokens[1:]
-			case css.PercentageToken:
-				n, err := strconv.ParseUint(tokens[0].Value, 10, 8)
-				if err != nil {
-					return nil, err
-				}
-				if n > 100 {
-					return nil, fmt.Errorf("percentage %v%% is out of range", n)
-				}
-				args, tokens = append(args, byte(255*100/n)), tokens[1:]
-			default:
-				return nil, errors.New("expected a number or percentage")
-			}
+// clampByte converts a value in the range [0, 1] to a byte, clamping it as necessary.
+func clampByte(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 1:
+		return 255
+	default:
+		return uint8(v*255 + 0.5)
+	}
+}
 
-			if len(tokens) == 0 {
-				return nil, errors.New("expected ',' or ')'")
-			}
+// parseColorFunction parses a color function, e.g. `rgb(255, 0, 0)`. The tokens must have
+// been matched by the <color> grammar.
+func parseColorFunction(tokens []cssToken) (color.Color, error) {
+	fn := tokens[0].Value
 
-			if tokens[0].Type == css.RightParenthesisToken {
-				tokens = tokens[1:]
-				break
-			}
-			if tokens[0].Type != css.CommaToken {
-				return nil, errors.New("expected ','")
-			}
-			tokens = tokens[1:]
+	var args []cssToken
+	for _, t := range tokens[1 : len(tokens)-1] {
+		if t.Type != css.CommaToken {
+			args = append(args, t)
 		}
 	}
 
-	if len(tokens) != 0 {
-		return nil, errors.New("garbage after function call")
+	var r, g, b, a float64
+	switch fn {
+	case "rgb(", "rgba(":
+		for i, c := range []*float64{&r, &g, &b} {
+			v, err := parseColorComponent(args[i])
+			if err != nil {
+				return nil, err
+			}
+			*c = v / 255
+		}
+	case "hsl(", "hsla(":
+		h, err := parseAngle(args[0])
+		if err != nil {
+			return nil, err
+		}
+		s, err := parsePercentage(args[1])
+		if err != nil {
+			return nil, err
+		}
+		l, err := parsePercentage(args[2])
+		if err != nil {
+			return nil, err
+		}
+		h = math.Mod(h, 360)
+		if h < 0 {
+			h += 360
+		}
+		r, g, b = hslToRGB(h/360, math.Min(math.Max(s, 0), 1), math.Min(math.Max(l, 0), 1))
+	default:
+		return nil, fmt.Errorf("unknown color function %v", fn)
 	}
 
-	if len(args) != arity {
-		return nil, fmt.Errorf("%v requires %v arguments", fn, arity)
+	a = 1
+	if len(args) == 4 {
+		v, err := parseAlphaValue(args[3])
+		if err != nil {
+			return nil, err
+		}
+		a = v
 	}
 
-	var r, g, b, a byte
-	switch fn {
-	case "rgb(":
-		r, g, b, a = args[0], args[1], args[2], 255
-	case "rgba(":
-		r, g, b, a = args[0], args[1], args[2], args[3]
-	case "hsl(":
-		r, g, b = hslToRGB(args[0], args[1], args[2])
-		a = 255
-	case "hsla(":
-		r, g, b = hslToRGB(args[0], args[1], args[2])
-		a = args[3]
-	}
-	return &color.RGBA{R: r, G: g, B: b, A: a}, nil
+	return &color.NRGBA{R: clampByte(r), G: clampByte(g), B: clampByte(b), A: clampByte(a)}, nil
 }
 
 func parseHexColor(v string) (color.Color, error) {
 	switch len(v) {
-	case 3:
-		v = string([]byte{v[0], v[0], v[1], v[1], v[2], v[2]})
-	case 6:
+	case 3, 4:
+		expanded := make([]byte, 0, 8)
+		for i := 0; i < len(v); i++ {
+			expanded = append(expanded, v[i], v[i])
+		}
+		v = string(expanded)
+	case 6, 8:
 		//  OK
 	default:
 		return nil, fmt.Errorf("invalid hex color %v", v)
@@ -527,35 +501,34 @@ func parseHexColor(v string) (color.Color, error) {
 		return nil, err
 	}
 
+	if len(bytes) == 4 {
+		return &color.NRGBA{R: bytes[0], G: bytes[1], B: bytes[2], A: bytes[3]}, nil
+	}
 	return &color.RGBA{R: bytes[0], G: bytes[1], B: bytes[2], A: 255}, nil
 }
 
+// parseColor parses a color. The tokens must have been matched by the <color> grammar.
 func parseColor(tokens []cssToken) (color.Color, error) {
-	if tokens[0].Type == css.FunctionToken {
-		return parseColorFunction(tokens)
-	}
-
-	if len(tokens) != 1 {
-		return nil, errors.New("unexpected token")
-	}
-
 	switch tokens[0].Type {
-	case css.IdentToken:
+	case css.FunctionToken:
+		return parseColorFunction(tokens)
+	case css.HashToken:
+		return parseHexColor(tokens[0].Value[1:])
+	default:
 		ident := tokens[0].Value
-		color, ok := cssColors[ident]
+		if strings.EqualFold(ident, "transparent") {
+			return color.Transparent, nil
+		}
+		color, ok := cssColors[strings.ToLower(ident)]
 		if !ok {
 			return nil, fmt.Errorf("unknown color %v", ident)
 		}
 		return color, nil
-	case css.HashToken:
-		return parseHexColor(tokens[0].Value[1:])
-	default:
-		return nil, errors.New("expected an identifier or hex color")
 	}
 }
 
 func (c *Color) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	tokens, err := matchTokens("<color>", text)
 	if err != nil {
 		return err
 	}
@@ -576,33 +549,25 @@ type Paint struct {
 }
 
 func (p *Paint) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
-	if err != nil {
-		return err
-	}
-
-	if len(tokens) == 0 {
+	if strings.TrimSpace(string(text)) == "" {
 		p.Color = color.Black
 		return nil
 	}
 
-	if tokens[0].Type == css.URLToken {
-		url := tokens[0].Value
-		p.URL = url[len("url(") : len(url)-1]
+	tokens, err := matchTokens("<paint>", text)
+	if err != nil {
+		return err
+	}
 
-		tokens = tokens[1:]
+	if tokens[0].Type == css.URLToken {
+		p.URL, tokens = parseURL(tokens[0]), tokens[1:]
 		if len(tokens) == 0 {
 			return nil
 		}
 	}
 
 	if tokens[0].Type == css.IdentToken {
-		if len(tokens) != 1 {
-			return errors.New("unexpected token")
-		}
-
-		ident := tokens[0].Value
-		switch ident {
+		switch ident := tokens[0].Value; ident {
 		case "context-fill", "context-stroke":
 			p.Context = ident
 			return nil
@@ -625,54 +590,307 @@ type FontFamily struct {
 }
 
 func (ff *FontFamily) UnmarshalText(text []byte) error {
-	tokens, err := cssTokens(string(text))
+	captures, err := matchValue("[ <generic-family> | <family-name> ]#", text)
 	if err != nil {
 		return err
 	}
 
 	var values []string
-	for len(tokens) > 0 {
-		switch tokens[0].Type {
-		case css.StringToken:
-			values, tokens = append(values, tokens[0].Value), tokens[1:]
-		case css.IdentToken:
-			var f strings.Builder
-			for len(tokens) > 0 {
-				if tokens[0].Type == css.CommaToken {
-					break
-				}
-				switch tokens[0].Type {
-				case css.WhitespaceToken, css.IdentToken:
-					f.WriteString(tokens[0].Value)
-					tokens = tokens[1:]
-				default:
-					return errors.New("expected an identifier or ','")
-				}
+	for _, c := range captures {
+		switch c.Name {
+		case "generic-family":
+			values = append(values, c.Values[0].Value)
+		case "family-name":
+			if c.Values[0].Type == css.StringToken {
+				v := c.Values[0].Value
+				values = append(values, v[1:len(v)-1])
+				continue
 			}
-			values = append(values, f.String())
-		default:
-			return errors.New("expected a string or identifier")
+
+			names := make([]string, len(c.Values))
+			for i, t := range c.Values {
+				names[i] = t.Value
+			}
+			values = append(values, strings.Join(names, " "))
 		}
+	}
 
-		if len(tokens) == 0 {
-			break
+	ff.Values = values
+	return nil
+}
+
+// ViewBox represents the value of an SVG `viewBox` attribute.
+type ViewBox struct {
+	MinX   float64
+	MinY   float64
+	Width  float64
+	Height float64
+}
+
+func (vb *ViewBox) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("<number> ,? <number> ,? <number> ,? <number>", text)
+	if err != nil {
+		return err
+	}
+
+	var values []float64
+	for _, token := range tokens {
+		if token.Type == css.NumberToken {
+			n, err := parseNumber(token)
+			if err != nil {
+				return err
+			}
+			values = append(values, n)
 		}
-		if tokens[0].Type != css.CommaToken {
-			return errors.New("expected a ','")
+	}
+	if values[2] < 0 || values[3] < 0 {
+		return errors.New("viewBox width and height must not be negative")
+	}
+
+	*vb = ViewBox{MinX: values[0], MinY: values[1], Width: values[2], Height: values[3]}
+	return nil
+}
+
+// PreserveAspectRatio represents the value of an SVG `preserveAspectRatio` attribute.
+type PreserveAspectRatio struct {
+	Align       string
+	MeetOrSlice string
+}
+
+func (par *PreserveAspectRatio) UnmarshalText(text []byte) error {
+	const grammar = "[ none | xMinYMin | xMidYMin | xMaxYMin | xMinYMid | xMidYMid | xMaxYMid | xMinYMax | xMidYMax | xMaxYMax ] [ meet | slice ]?"
+
+	tokens, err := matchTokens(grammar, text)
+	if err != nil {
+		return err
+	}
+
+	par.Align, par.MeetOrSlice = tokens[0].Value, "meet"
+	if len(tokens) == 2 {
+		par.MeetOrSlice = tokens[1].Value
+	}
+	return nil
+}
+
+// BasicShape represents a CSS basic shape, e.g. `circle(50% at 0 0)`.
+type BasicShape struct {
+	// Function is the name of the shape function: inset, circle, ellipse, or polygon.
+	Function string
+
+	// Args holds the shape's lengths. For inset, these are the top, right, bottom, and left
+	// offsets. For circle and ellipse, these are the radii, and for polygon, these are the
+	// coordinates of the vertices.
+	Args []LengthPercentageIdent
+
+	// Radius is the corner radius of an inset.
+	Radius *LengthPercentage
+
+	// Position is the center of a circle or ellipse. Keywords are converted to percentages.
+	Position *[2]LengthPercentage
+
+	// FillRule is the fill rule of a polygon.
+	FillRule string
+}
+
+// parsePosition parses a one- or two-value CSS position, e.g. `left 10%`.
+func parsePosition(tokens []cssToken) (*[2]LengthPercentage, error) {
+	keywords := map[string]float64{"left": 0, "top": 0, "center": 0.5, "right": 1, "bottom": 1}
+
+	var position [2]LengthPercentage
+	for i := range position {
+		position[i] = LengthPercentage{Percentage: 0.5}
+	}
+	swap := false
+	for i, token := range tokens {
+		if token.Type == css.IdentToken {
+			keyword := strings.ToLower(token.Value)
+			position[i] = LengthPercentage{Percentage: keywords[keyword]}
+			swap = swap || i == 0 && (keyword == "top" || keyword == "bottom") || i == 1 && (keyword == "left" || keyword == "right")
+			continue
+		}
+
+		lp, err := parseLengthPercentage(token)
+		if err != nil {
+			return nil, err
+		}
+		position[i] = lp
+	}
+	if swap {
+		position[0], position[1] = position[1], position[0]
+	}
+	return &position, nil
+}
+
+func (bs *BasicShape) unmarshalTokens(tokens []cssToken) error {
+	fn := tokens[0].Value
+	bs.Function = fn[:len(fn)-1]
+
+	args := tokens[1:]
+	for i := 0; i < len(args); i++ {
+		token := args[i]
+		switch {
+		case token.Type == css.IdentToken && token.Value == "at":
+			end := i + 1
+			for end < len(args) && args[end].Type != css.RightParenthesisToken {
+				end++
+			}
+			position, err := parsePosition(args[i+1 : end])
+			if err != nil {
+				return err
+			}
+			bs.Position, i = position, end
+		case token.Type == css.IdentToken && token.Value == "round":
+			radius, err := parseLengthPercentage(args[i+1])
+			if err != nil {
+				return err
+			}
+			bs.Radius = &radius
+
+			// Only uniform corner radii are supported.
+			for i++; i+1 < len(args) && args[i+1].Type != css.RightParenthesisToken; i++ {
+			}
+		case token.Type == css.IdentToken && (token.Value == "nonzero" || token.Value == "evenodd"):
+			bs.FillRule = token.Value
+		case token.Type == css.IdentToken:
+			bs.Args = append(bs.Args, LengthPercentageIdent{Ident: token.Value})
+		case token.Type == css.NumberToken || token.Type == css.DimensionToken || token.Type == css.PercentageToken:
+			lp, err := parseLengthPercentage(token)
+			if err != nil {
+				return err
+			}
+			bs.Args = append(bs.Args, LengthPercentageIdent{LengthPercentage: lp})
+		}
+	}
+
+	// Expand inset offsets using the same rules as the CSS margin shorthand.
+	if bs.Function == "inset" {
+		a := bs.Args
+		switch len(a) {
+		case 1:
+			bs.Args = []LengthPercentageIdent{a[0], a[0], a[0], a[0]}
+		case 2:
+			bs.Args = []LengthPercentageIdent{a[0], a[1], a[0], a[1]}
+		case 3:
+			bs.Args = []LengthPercentageIdent{a[0], a[1], a[2], a[1]}
 		}
+	}
+	return nil
+}
+
+// ClipSource represents the value of the clip-path property: either a reference to a
+// clipPath element or a basic shape and its reference box.
+type ClipSource struct {
+	URL   string
+	Shape *BasicShape
+	Box   string
+}
+
+func (cs *ClipSource) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("none | <url> | [ <basic-shape> || <geometry-box> ]", text)
+	if err != nil {
+		return err
+	}
+
+	*cs = ClipSource{}
+	switch token := tokens[0]; {
+	case token.Type == css.URLToken:
+		cs.URL = parseURL(token)
+	case token.Type == css.IdentToken && token.Value == "none":
+	case token.Type == css.IdentToken:
+		cs.Box = token.Value
 		tokens = tokens[1:]
+		fallthrough
+	default:
+		if len(tokens) != 0 && tokens[0].Type == css.FunctionToken {
+			end := 0
+			for tokens[end].Type != css.RightParenthesisToken {
+				end++
+			}
+			cs.Shape = &BasicShape{}
+			if err := cs.Shape.unmarshalTokens(tokens[:end+1]); err != nil {
+				return err
+			}
+			tokens = tokens[end+1:]
+		}
+		if len(tokens) != 0 {
+			cs.Box = tokens[0].Value
+		}
+	}
+	return nil
+}
+
+// FilterList represents the value of the filter property, a list of references to filter
+// elements.
+type FilterList struct {
+	URLs []string
+}
+
+func (fl *FilterList) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("none | <url>+", text)
+	if err != nil {
+		return err
 	}
 
-	ff.Values = values
+	var urls []string
+	for _, token := range tokens {
+		if token.Type == css.URLToken {
+			urls = append(urls, parseURL(token))
+		}
+	}
+	fl.URLs = urls
+	return nil
+}
+
+// Orient represents the value of a marker's orient attribute. If Ident is empty, the marker
+// is rotated by Angle degrees.
+type Orient struct {
+	Ident string
+	Angle float64
+}
+
+func (o *Orient) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("auto | auto-start-reverse | <angle> | <number>", text)
+	if err != nil {
+		return err
+	}
+
+	token := tokens[0]
+	if token.Type == css.IdentToken {
+		o.Ident = token.Value
+		return nil
+	}
+	o.Angle, err = parseAngle(token)
+	return err
+}
+
+// DashArray represents a stroke-dasharray value. A DashArray with no values represents none.
+type DashArray struct {
+	Values []LengthPercentage
+}
+
+func (da *DashArray) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("none | [ <length-percentage> ,? ]+", text)
+	if err != nil {
+		return err
+	}
+
+	var values []LengthPercentage
+	for _, token := range tokens {
+		if token.Type == css.CommaToken || token.Type == css.IdentToken {
+			continue
+		}
+
+		v, err := parseLengthPercentage(token)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+	da.Values = values
 	return nil
 }
 
 // TODO
 
-type ClipPath string
 type Cursor string
-type DashArray string
-type FilterList string
-type Mask string
-type Transform string
 type VectorEffect string
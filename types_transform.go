@@ -0,0 +1,116 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/tdewolff/parse/v2/css"
+)
+
+// Transform represents a single transform function, e.g. `translate(10, 20)`.
+type Transform struct {
+	Function string
+	Args     []float64
+}
+
+// Matrix returns the affine transformation matrix described by the transform.
+func (t Transform) Matrix() gg.Matrix {
+	switch t.Function {
+	case "matrix":
+		a := t.Args
+		return gg.Matrix{XX: a[0], YX: a[1], XY: a[2], YY: a[3], X0: a[4], Y0: a[5]}
+	case "translate":
+		tx, ty := t.Args[0], 0.0
+		if len(t.Args) == 2 {
+			ty = t.Args[1]
+		}
+		return gg.Translate(tx, ty)
+	case "scale":
+		sx, sy := t.Args[0], t.Args[0]
+		if len(t.Args) == 2 {
+			sy = t.Args[1]
+		}
+		return gg.Scale(sx, sy)
+	case "rotate":
+		rotate := gg.Rotate(gg.Radians(t.Args[0]))
+		if len(t.Args) == 3 {
+			cx, cy := t.Args[1], t.Args[2]
+			return gg.Translate(-cx, -cy).Multiply(rotate).Multiply(gg.Translate(cx, cy))
+		}
+		return rotate
+	case "skewX":
+		return gg.Shear(math.Tan(gg.Radians(t.Args[0])), 0)
+	case "skewY":
+		return gg.Shear(0, math.Tan(gg.Radians(t.Args[0])))
+	default:
+		return gg.Identity()
+	}
+}
+
+// TransformList represents an SVG transform list.
+type TransformList struct {
+	Transforms []Transform
+}
+
+// Matrix returns the affine transformation matrix described by the transform list. The
+// transforms are applied from right to left, so the result maps the coordinate system
+// established by the last transform into the coordinate system of the list's context.
+func (tl *TransformList) Matrix() gg.Matrix {
+	m := gg.Identity()
+	if tl == nil {
+		return m
+	}
+	for _, t := range tl.Transforms {
+		m = t.Matrix().Multiply(m)
+	}
+	return m
+}
+
+// parseTransformArgument parses a transform function argument. In addition to the bare
+// numbers permitted by SVG, the CSS forms of lengths in pixels and angles are accepted.
+func parseTransformArgument(token cssToken) (float64, error) {
+	if token.Type == css.NumberToken {
+		return parseNumber(token)
+	}
+
+	n, units, err := splitDimension(token)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(units) {
+	case "px":
+		return n, nil
+	case "deg", "rad", "grad", "turn":
+		return parseAngle(token)
+	default:
+		return 0, fmt.Errorf("unsupported units %v", units)
+	}
+}
+
+func (tl *TransformList) UnmarshalText(text []byte) error {
+	tokens, err := matchTokens("none | <transform-list>", text)
+	if err != nil {
+		return err
+	}
+
+	var transforms []Transform
+	for _, token := range tokens {
+		switch token.Type {
+		case css.FunctionToken:
+			fn := token.Value
+			transforms = append(transforms, Transform{Function: fn[:len(fn)-1]})
+		case css.NumberToken, css.DimensionToken:
+			arg, err := parseTransformArgument(token)
+			if err != nil {
+				return err
+			}
+			t := &transforms[len(transforms)-1]
+			t.Args = append(t.Args, arg)
+		}
+	}
+
+	tl.Transforms = transforms
+	return nil
+}
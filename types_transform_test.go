@@ -0,0 +1,51 @@
+package svg
+
+import (
+	"testing"
+
+	"github.com/fogleman/gg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformList(t *testing.T) {
+	cases := []struct {
+		input  string
+		x, y   float64
+		tx, ty float64
+	}{
+		{input: "translate(10)", x: 1, y: 1, tx: 11, ty: 1},
+		{input: "translate(10, 20) scale(2)", x: 1, y: 1, tx: 12, ty: 22},
+		{input: "scale(2 3)translate(1,1)", x: 0, y: 0, tx: 2, ty: 3},
+		{input: "rotate(90 10 10)", x: 20, y: 10, tx: 10, ty: 20},
+		{input: "matrix(1,0,0,1,5,6)", x: 1, y: 2, tx: 6, ty: 8},
+		{input: "skewX(45)", x: 0, y: 1, tx: 1, ty: 1},
+		{input: "rotate(90deg)", x: 1, y: 0, tx: 0, ty: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			var tl TransformList
+			require.NoError(t, tl.UnmarshalText([]byte(c.input)))
+
+			tx, ty := tl.Matrix().TransformPoint(c.x, c.y)
+			assert.InDelta(t, c.tx, tx, 1e-9)
+			assert.InDelta(t, c.ty, ty, 1e-9)
+		})
+	}
+}
+
+func TestApplyMatrix(t *testing.T) {
+	var tl TransformList
+	require.NoError(t, tl.UnmarshalText([]byte("translate(3 4) rotate(30) skewY(10) scale(2 -1)")))
+
+	ctx := gg.NewContext(1, 1)
+	require.True(t, applyMatrix(ctx, tl.Matrix()))
+
+	expected, actual := tl.Matrix(), currentMatrix(ctx)
+	assert.InDelta(t, expected.XX, actual.XX, 1e-9)
+	assert.InDelta(t, expected.YX, actual.YX, 1e-9)
+	assert.InDelta(t, expected.XY, actual.XY, 1e-9)
+	assert.InDelta(t, expected.YY, actual.YY, 1e-9)
+	assert.InDelta(t, expected.X0, actual.X0, 1e-9)
+	assert.InDelta(t, expected.Y0, actual.Y0, 1e-9)
+}
@@ -1,37 +1,13 @@
 package svg
 
 import (
-	"io"
-	"strings"
-
-	"github.com/tdewolff/parse/v2"
-	"github.com/tdewolff/parse/v2/css"
+	"github.com/pgavlin/svg2/internal/cssvalue"
 )
 
-type cssToken struct {
-	Type  css.TokenType
-	Value string
-}
-
-func cssTokens(s string) ([]cssToken, error) {
-	var tokens []cssToken
-
-	l := css.NewLexer(parse.NewInput(strings.NewReader(s)))
-	for {
-		typ, value := l.Next()
-		if typ == css.ErrorToken {
-			if l.Err() == io.EOF {
-				break
-			}
-			return nil, l.Err()
-		}
-		tokens = append(tokens, cssToken{Type: typ, Value: string(value)})
-	}
-
-	return tokens, nil
-}
+// cssToken is a single CSS token.
+type cssToken = cssvalue.Token
 
-func hueToRGB(m1, m2, h float64) byte {
+func hueToRGB(m1, m2, h float64) float64 {
 	switch {
 	case h < 0:
 		h += 1
@@ -41,25 +17,25 @@ func hueToRGB(m1, m2, h float64) byte {
 
 	switch {
 	case h*6 < 1:
-		return byte(m1 + (m2-m1)*h*6*255)
+		return m1 + (m2-m1)*h*6
 	case h*2 < 1:
-		return byte(m2 * 255)
+		return m2
 	case h*3 < 2:
-		return byte(m1 + (m2-m1)*(2/3-h)*6*255)
+		return m1 + (m2-m1)*(2.0/3-h)*6
 	}
-	return byte(m1 * 255)
+	return m1
 }
 
-func hslToRGB(h, s, l byte) (r, g, b byte) {
-	hf, sf, lf := float64(h)/255, float64(s)/255, float64(l)/255
-
+// hslToRGB converts a color in the HSL color space to the RGB color space. The hue,
+// saturation, and lightness are given in the range [0, 1], as are the resulting components.
+func hslToRGB(h, s, l float64) (r, g, b float64) {
 	var m2 float64
-	if lf <= 0.5 {
-		m2 = lf * (sf + 1)
+	if l <= 0.5 {
+		m2 = l * (s + 1)
 	} else {
-		m2 = lf + sf - lf*sf
+		m2 = l + s - l*s
 	}
 
-	m1 := lf*2 - m2
-	return hueToRGB(m1, m2, hf+1/3), hueToRGB(m1, m2, hf), hueToRGB(m1, m2, hf-1/3)
+	m1 := l*2 - m2
+	return hueToRGB(m1, m2, h+1.0/3), hueToRGB(m1, m2, h), hueToRGB(m1, m2, h-1.0/3)
 }
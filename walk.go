@@ -13,33 +13,62 @@ func walkElements(es []any, visitor func(e Element)) {
 
 func walkElement(e Element, visitor func(e Element)) {
 	visitor(e)
+	walkElements(children(e), visitor)
+}
 
+// children returns the children of the given element.
+func children(e Element) []any {
 	switch e := e.(type) {
 	case *Grouping:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Defs:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Symbol:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Switch:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Marker:
-		walkElements(e.Children, visitor)
+		return e.Children
+	case *ClipPath:
+		return e.Children
+	case *Mask:
+		return e.Children
+	case *Filter:
+		return e.Children
 	case *Pattern:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Path:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Rect:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Circle:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Ellipse:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Line:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Polyline:
-		walkElements(e.Children, visitor)
+		return e.Children
 	case *Polygon:
-		walkElements(e.Children, visitor)
+		return e.Children
+	case *Text:
+		return e.Children
+	case *TSpan:
+		return e.Children
+	case *TextPath:
+		return e.Children
 	}
+	return nil
+}
+
+// parents returns a map from each element in the given document to its parent. Top-level
+// elements have no entry.
+func parents(svg *SVG) map[Element]Element {
+	m := map[Element]Element{}
+	walk(svg, func(e Element) {
+		for _, c := range children(e) {
+			m[c.X] = e
+		}
+	})
+	return m
 }